@@ -2,35 +2,36 @@ package cgroups
 
 import (
 	"bufio"
-	"os"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// BlkioStat holds the v1 blkio stats, or on v2 those of them that io.stat
+// provides (bytes and IOs), summed over all devices.
 type BlkioStat struct {
 	// From other files
-	Merged            uint64 `file:"blkio.io_merged_recursive"`
-	MergedRead        uint64 `file:"blkio.io_merged_recursive" sum:"Read"`
-	MergedWrite       uint64 `file:"blkio.io_merged_recursive" sum:"Write"`
-	Queued            uint64 `file:"blkio.io_queued_recursive"`
-	QueuedRead        uint64 `file:"blkio.io_queued_recursive" sum:"Read"`
-	QueuedWrite       uint64 `file:"blkio.io_queued_recursive" sum:"Write"`
-	ServiceBytes      uint64 `file:"blkio.io_service_bytes_recursive"`
-	ServiceBytesRead  uint64 `file:"blkio.io_service_bytes_recursive" sum:"Read"`
-	ServiceBytesWrite uint64 `file:"blkio.io_service_bytes_recursive" sum:"Write"`
-	Serviced          uint64 `file:"blkio.io_serviced_recursive"`
-	ServicedRead      uint64 `file:"blkio.io_serviced_recursive" sum:"Read"`
-	ServicedWrite     uint64 `file:"blkio.io_serviced_recursive" sum:"Write"`
-	ServiceTime       uint64 `file:"blkio.io_service_time_recursive"`
-	ServiceTimeRead   uint64 `file:"blkio.io_service_time_recursive" sum:"Read"`
-	ServiceTimeWrite  uint64 `file:"blkio.io_service_time_recursive" sum:"Write"`
-	WaitTime          uint64 `file:"blkio.io_wait_time_recursive"`
-	WaitTimeRead      uint64 `file:"blkio.io_wait_time_recursive" sum:"Read"`
-	WaitTimeWrite     uint64 `file:"blkio.io_wait_time_recursive" sum:"Write"`
-
-	SampleTime        time.Time
+	Merged            uint64 `file:"blkio.io_merged_recursive" json:"merged,omitempty"`
+	MergedRead        uint64 `file:"blkio.io_merged_recursive" sum:"Read" json:"merged_read,omitempty"`
+	MergedWrite       uint64 `file:"blkio.io_merged_recursive" sum:"Write" json:"merged_write,omitempty"`
+	Queued            uint64 `file:"blkio.io_queued_recursive" json:"queued,omitempty"`
+	QueuedRead        uint64 `file:"blkio.io_queued_recursive" sum:"Read" json:"queued_read,omitempty"`
+	QueuedWrite       uint64 `file:"blkio.io_queued_recursive" sum:"Write" json:"queued_write,omitempty"`
+	ServiceBytes      uint64 `file:"blkio.io_service_bytes_recursive" iostat:"rbytes,wbytes,dbytes" json:"service_bytes,omitempty"`
+	ServiceBytesRead  uint64 `file:"blkio.io_service_bytes_recursive" sum:"Read" iostat:"rbytes" json:"service_bytes_read,omitempty"`
+	ServiceBytesWrite uint64 `file:"blkio.io_service_bytes_recursive" sum:"Write" iostat:"wbytes" json:"service_bytes_write,omitempty"`
+	Serviced          uint64 `file:"blkio.io_serviced_recursive" iostat:"rios,wios,dios" json:"serviced,omitempty"`
+	ServicedRead      uint64 `file:"blkio.io_serviced_recursive" sum:"Read" iostat:"rios" json:"serviced_read,omitempty"`
+	ServicedWrite     uint64 `file:"blkio.io_serviced_recursive" sum:"Write" iostat:"wios" json:"serviced_write,omitempty"`
+	ServiceTime       uint64 `file:"blkio.io_service_time_recursive" json:"service_time_ns,omitempty"`
+	ServiceTimeRead   uint64 `file:"blkio.io_service_time_recursive" sum:"Read" json:"service_time_read_ns,omitempty"`
+	ServiceTimeWrite  uint64 `file:"blkio.io_service_time_recursive" sum:"Write" json:"service_time_write_ns,omitempty"`
+	WaitTime          uint64 `file:"blkio.io_wait_time_recursive" json:"wait_time_ns,omitempty"`
+	WaitTimeRead      uint64 `file:"blkio.io_wait_time_recursive" sum:"Read" json:"wait_time_read_ns,omitempty"`
+	WaitTimeWrite     uint64 `file:"blkio.io_wait_time_recursive" sum:"Write" json:"wait_time_write_ns,omitempty"`
+
+	SampleTime time.Time `json:"-"`
 }
 
 func (s BlkioStat) Delta(prevStats BlkioStat) BlkioDeltaStat {
@@ -42,18 +43,18 @@ type BlkioItemizedStats struct {
 }
 
 type BlkioDeltaStat struct {
-	IoRate                uint64
-	IoRateRead            uint64
-	IoRateWrite           uint64
-	ByteRate              uint64
-	ByteRateRead          uint64
-	ByteRateWrite         uint64
-	AvgServiceTimeNs      uint64
-	AvgServiceTimeReadNs  uint64
-	AvgServiceTimeWriteNs uint64
-	AvgWaitTimeNs         uint64
-	AvgWaitTimeReadNs     uint64
-	AvgWaitTimeWriteNs    uint64
+	IoRate                uint64 `json:"ios_per_sec,omitempty"`
+	IoRateRead            uint64 `json:"read_ios_per_sec,omitempty"`
+	IoRateWrite           uint64 `json:"write_ios_per_sec,omitempty"`
+	ByteRate              uint64 `json:"bytes_per_sec,omitempty"`
+	ByteRateRead          uint64 `json:"read_bytes_per_sec,omitempty"`
+	ByteRateWrite         uint64 `json:"write_bytes_per_sec,omitempty"`
+	AvgServiceTimeNs      uint64 `json:"avg_service_time_ns,omitempty"`
+	AvgServiceTimeReadNs  uint64 `json:"avg_service_time_read_ns,omitempty"`
+	AvgServiceTimeWriteNs uint64 `json:"avg_service_time_write_ns,omitempty"`
+	AvgWaitTimeNs         uint64 `json:"avg_wait_time_ns,omitempty"`
+	AvgWaitTimeReadNs     uint64 `json:"avg_wait_time_read_ns,omitempty"`
+	AvgWaitTimeWriteNs    uint64 `json:"avg_wait_time_write_ns,omitempty"`
 }
 
 const (
@@ -86,19 +87,19 @@ func CalcBlkioDeltaStats(stats BlkioStat, prevStats BlkioStat) BlkioDeltaStat {
 
 	// +1 fudging to avoid div-by-zero - shouldn't matter
 	// at relevant sample sizes.
-	deltaStat.AvgServiceTimeReadNs = rdServiceTimeDelta / (rdIoDelta+1)
-	deltaStat.AvgServiceTimeWriteNs = wrServiceTimeDelta / (wrIoDelta+1)
-	deltaStat.AvgServiceTimeNs = serviceTimeDelta / (allIoDelta+1)
+	deltaStat.AvgServiceTimeReadNs = rdServiceTimeDelta / (rdIoDelta + 1)
+	deltaStat.AvgServiceTimeWriteNs = wrServiceTimeDelta / (wrIoDelta + 1)
+	deltaStat.AvgServiceTimeNs = serviceTimeDelta / (allIoDelta + 1)
 
-	deltaStat.AvgWaitTimeReadNs = rdWaitTimeDelta / (rdIoDelta+1)
-	deltaStat.AvgWaitTimeWriteNs = wrWaitTimeDelta / (wrIoDelta+1)
-	deltaStat.AvgWaitTimeNs = waitTimeDelta / (allIoDelta+1)
+	deltaStat.AvgWaitTimeReadNs = rdWaitTimeDelta / (rdIoDelta + 1)
+	deltaStat.AvgWaitTimeWriteNs = wrWaitTimeDelta / (wrIoDelta + 1)
+	deltaStat.AvgWaitTimeNs = waitTimeDelta / (allIoDelta + 1)
 
 	return deltaStat
 }
 
-func blkioParse(filePath string, sumField string) (uint64, error) {
-	fd, err := os.Open(filePath)
+func blkioParse(cg Cgroup, filePath string, sumField string) (uint64, error) {
+	fd, err := fsOpen(cg, filePath)
 	if err != nil {
 		return 0, err
 	}
@@ -155,7 +156,7 @@ func populateBlkioOther(cg Cgroup, stat *BlkioStat) error {
 			return err
 		}
 
-		value, err := blkioParse(path, tag.Get("sum"))
+		value, err := blkioParse(cg, path, tag.Get("sum"))
 		if err != nil {
 			continue
 		}
@@ -166,9 +167,87 @@ func populateBlkioOther(cg Cgroup, stat *BlkioStat) error {
 	return nil
 }
 
+// populateBlkioIoStat reads the per-device stats from io.stat (v2). A
+// missing io.stat, as in the root cgroup, leaves them empty.
+func populateBlkioIoStat(cg Cgroup, stats map[string]BlkioStat) error {
+	path, err := GetCgroupPath(cg, ControllerBlkio, "io.stat")
+	if err != nil {
+		return err
+	}
+
+	fd, err := fsOpen(cg, path)
+	if err != nil {
+		return nil
+	}
+	defer fd.Close()
+
+	sampleTime := time.Now()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		// major:minor key=value...
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 {
+			continue
+		}
+
+		values := make(map[string]uint64)
+		for _, kv := range parts[1:] {
+			kvParts := strings.SplitN(kv, "=", 2)
+			if len(kvParts) != 2 {
+				continue
+			}
+
+			v, err := strconv.ParseUint(kvParts[1], 10, 64)
+			if err != nil {
+				continue
+			}
+
+			values[kvParts[0]] = v
+		}
+
+		stat := stats[parts[0]]
+		stat.SampleTime = sampleTime
+
+		sv := reflect.ValueOf(&stat).Elem()
+		for i := 0; i < sv.NumField(); i++ {
+			keys := sv.Type().Field(i).Tag.Get("iostat")
+			if keys == "" {
+				continue
+			}
+
+			var value uint64
+			for _, key := range strings.Split(keys, ",") {
+				value += values[key]
+			}
+
+			sv.Field(i).SetUint(value)
+		}
+
+		stats[parts[0]] = stat
+	}
+
+	return scanner.Err()
+}
+
 func GetBlkioStats(cg Cgroup) (BlkioStat, error) {
 	var stats BlkioStat
 
+	if isUnified(cg, ControllerBlkio) {
+		devices := make(map[string]BlkioStat)
+		if err := populateBlkioIoStat(cg, devices); err != nil {
+			return stats, err
+		}
+
+		stats.SampleTime = time.Now()
+
+		for _, dev := range devices {
+			sumBlkioStats(&stats, dev)
+		}
+
+		return stats, nil
+	}
+
 	err := populateBlkioOther(cg, &stats)
 	if err != nil {
 		return stats, err
@@ -177,11 +256,115 @@ func GetBlkioStats(cg Cgroup) (BlkioStat, error) {
 	return stats, nil
 }
 
+// GetBlkioItemizedStats returns the stats per device, keyed by device
+// name (or major:minor if the name cannot be resolved).
 func GetBlkioItemizedStats(cg Cgroup) (BlkioItemizedStats, error) {
 	var stats BlkioItemizedStats
 	stats.Stats = make(map[string]BlkioStat)
 
-	// XXX: TODO
+	if isUnified(cg, ControllerBlkio) {
+		devices := make(map[string]BlkioStat)
+		if err := populateBlkioIoStat(cg, devices); err != nil {
+			return stats, err
+		}
+
+		for majMin, stat := range devices {
+			stats.Stats[blockDeviceName(cg, majMin)] = stat
+		}
+
+		return stats, nil
+	}
+
+	sampleTime := time.Now()
+
+	parsed := make(map[string]map[string]map[string]uint64)
+
+	v := reflect.ValueOf(BlkioStat{})
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag
+
+		fileName := tag.Get("file")
+		if fileName == "" {
+			continue
+		}
+
+		devices, ok := parsed[fileName]
+		if !ok {
+			path, err := GetCgroupPath(cg, ControllerBlkio, fileName)
+			if err == ErrNoCgroup {
+				return stats, err
+			}
+
+			devices, _ = blkioParseDevices(cg, path)
+			parsed[fileName] = devices
+		}
+
+		sumField := strings.ToLower(tag.Get("sum"))
+		if sumField == "" {
+			sumField = "total"
+		}
+
+		for majMin, values := range devices {
+			dev := blockDeviceName(cg, majMin)
+
+			stat, ok := stats.Stats[dev]
+			if !ok {
+				stat.SampleTime = sampleTime
+			}
+
+			sv := reflect.ValueOf(&stat).Elem()
+			sv.Field(i).SetUint(values[sumField])
+
+			stats.Stats[dev] = stat
+		}
+	}
 
 	return stats, nil
 }
+
+// blkioParseDevices parses "major:minor Op value" lines into per-device
+// maps keyed by lower-cased operation.
+func blkioParseDevices(cg Cgroup, filePath string) (map[string]map[string]uint64, error) {
+	devices := make(map[string]map[string]uint64)
+
+	fd, err := fsOpen(cg, filePath)
+	if err != nil {
+		return devices, err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 3 {
+			continue
+		}
+
+		v, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := devices[parts[0]]; !ok {
+			devices[parts[0]] = make(map[string]uint64)
+		}
+
+		devices[parts[0]][strings.ToLower(parts[1])] = v
+	}
+
+	return devices, nil
+}
+
+// sumBlkioStats adds the counters of stat to total.
+func sumBlkioStats(total *BlkioStat, stat BlkioStat) {
+	tv := reflect.ValueOf(total).Elem()
+	sv := reflect.ValueOf(stat)
+
+	for i := 0; i < tv.NumField(); i++ {
+		if tv.Field(i).Kind() != reflect.Uint64 {
+			continue
+		}
+
+		tv.Field(i).SetUint(tv.Field(i).Uint() + sv.Field(i).Uint())
+	}
+}
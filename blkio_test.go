@@ -1,16 +1,43 @@
 package cgroups
 
 import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
 )
 
 func TestBlkioStat(t *testing.T) {
-	stats, err := GetBlkioStats(Cgroup{ Cgroup: "/system.slice" })
+	for _, fsys := range []fs.FS{fixtures.V1, fixtures.Hybrid} {
+		testBlkioStat(t, fsys)
+	}
+
+	// v2 only has bytes and IOs, from io.stat
+	stats, err := GetBlkioStats(fixtureCgroup(fixtures.V2))
+	if err != nil || stats.ServiceBytesRead != 105906176 || stats.ServicedWrite != 1280 || stats.Serviced != 3820 || stats.ServiceTime != 0 {
+		t.Errorf("%+v %v\n", stats, err)
+	}
+
+	devices, err := GetBlkioItemizedStats(fixtureCgroup(fixtures.V2))
+	if err != nil || devices.Stats["sda"].ServiceBytesWrite != 52428800 || devices.Stats["dm-0"].ServicedRead != 40 {
+		t.Errorf("%+v %v\n", devices, err)
+	}
+}
+
+func testBlkioStat(t *testing.T, fsys fs.FS) {
+	stats, err := GetBlkioStats(fixtureCgroup(fsys))
 
 	if err != nil {
 		t.Fail()
 	}
 
+	if stats.ServiceBytesRead != 105906176 || stats.ServicedWrite != 1280 {
+		t.Fail()
+	}
+
 	if stats.Serviced < stats.ServicedRead+stats.ServicedWrite {
 		t.Fail()
 	}
@@ -38,9 +65,56 @@ func TestBlkioStat(t *testing.T) {
 	t.Logf("%+v\n", stats)
 }
 
+func TestBlkioItemizedStats(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	contents := "250:1 Read 4096\n250:1 Write 8192\n250:1 Total 12288\n" +
+		"250:2 Read 10\n250:2 Write 0\n250:2 Total 10\nTotal 12298\n"
+	err = ioutil.WriteFile(path.Join(root, "blkio.io_service_bytes_recursive"), []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := GetBlkioItemizedStats(Cgroup{Root: root, Cgroup: "/"})
+
+	if err != nil {
+		t.Fail()
+	}
+
+	if len(stats.Stats) != 2 {
+		t.Fail()
+	}
+
+	dev := stats.Stats[GetBlockDeviceFromMajMin("250:1")]
+
+	if dev.ServiceBytes != 12288 || dev.ServiceBytesRead != 4096 || dev.ServiceBytesWrite != 8192 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stats)
+}
+
+func TestBlkioItemizedFixture(t *testing.T) {
+	stats, err := GetBlkioItemizedStats(fixtureCgroup(fixtures.V1))
+
+	if err != nil {
+		t.Fail()
+	}
+
+	if stats.Stats["sda"].ServiceBytesRead != 104857600 || stats.Stats["dm-0"].ServicedWrite != 80 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stats)
+}
+
 func BenchmarkBlkioStat(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := GetBlkioStats(Cgroup{ Cgroup: "/system.slice" })
+		_, err := GetBlkioStats(fixtureCgroup(fixtures.V1))
 		if err != nil {
 			b.Fail()
 		}
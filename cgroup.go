@@ -2,37 +2,43 @@ package cgroups
 
 import (
 	"errors"
-	"os"
+	"io/fs"
 	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type Cgroup struct {
-	Root      string // defaults to: DefaultSysfsRoot
-	Cgroup    string // e.g.: /machine.slice/foo.service
+	Root     string // defaults to: DefaultSysfsRoot, or fs/cgroup below SysRoot
+	Cgroup   string // e.g.: /machine.slice/foo.service
+	ProcRoot string // defaults to: DefaultProcRoot
+	SysRoot  string // defaults to: DefaultSysRoot
+	FS       fs.FS  // defaults to the host's filesystem, see fs.go
 }
 
 const (
 	DefaultSysfsRoot = "/sys/fs/cgroup"
+	DefaultProcRoot  = "/proc"
+	DefaultSysRoot   = "/sys"
 )
 
 var (
-	ErrNoCgroup = errors.New("go-cgroups: Could not find path to cgroup")
-	ErrNoStat   = errors.New("go-cgroups: Could not find cgroup stat file")
+	ErrNoCgroup      = errors.New("go-cgroups: Could not find path to cgroup")
+	ErrNoStat        = errors.New("go-cgroups: Could not find cgroup stat file")
+	ErrInvalidFormat = errors.New("go-cgroups: Unexpected file format")
 )
 
 func GetCgroupPath(cg Cgroup, controller string, file string) (string, error) {
 	cgDir := ""
-	root := cg.Root
-	if root == "" {
-		root = DefaultSysfsRoot
-	}
+	root := cgroupRoot(cg)
 
 	guesses := make([]string, 0, 5)
 	guesses = append(guesses, path.Join(root, controller, cg.Cgroup))
 	guesses = append(guesses, path.Join(root, cg.Cgroup))
 
 	for i := range guesses {
-		if _, err := os.Stat(guesses[i]); err != nil {
+		if _, err := fsStat(cg, guesses[i]); err != nil {
 			continue
 		}
 
@@ -50,3 +56,101 @@ func GetCgroupPath(cg Cgroup, controller string, file string) (string, error) {
 
 	return path.Join(cgDir, file), nil
 }
+
+func cgroupRoot(cg Cgroup) string {
+	if cg.Root != "" {
+		return cg.Root
+	}
+
+	if cg.SysRoot != "" {
+		return path.Join(cg.SysRoot, "fs/cgroup")
+	}
+
+	return DefaultSysfsRoot
+}
+
+// procPath returns the path of a file in the proc filesystem seen by cg,
+// e.g. procPath(cg, "1", "stat").
+func procPath(cg Cgroup, elem ...string) string {
+	root := cg.ProcRoot
+	if root == "" {
+		root = DefaultProcRoot
+	}
+
+	return path.Join(append([]string{root}, elem...)...)
+}
+
+// sysPath returns the path of a file in the sysfs seen by cg, e.g.
+// sysPath(cg, "dev/block").
+func sysPath(cg Cgroup, elem ...string) string {
+	root := cg.SysRoot
+	if root == "" {
+		root = DefaultSysRoot
+	}
+
+	return path.Join(append([]string{root}, elem...)...)
+}
+
+// withCgroup returns a Cgroup in the same hierarchy and filesystems as cg.
+func withCgroup(cg Cgroup, cgroup string) Cgroup {
+	cg.Cgroup = cgroup
+	return cg
+}
+
+// isUnified reports whether the controller's files for cg are found in a
+// v2 hierarchy.
+func isUnified(cg Cgroup, controller string) bool {
+	path, err := GetCgroupPath(cg, controller, "cgroup.controllers")
+	if err != nil {
+		return false
+	}
+
+	_, err = fsStat(cg, path)
+	return err == nil
+}
+
+func readCgroupFile(cg Cgroup, controller string, file string) (string, error) {
+	path, err := GetCgroupPath(cg, controller, file)
+	if err != nil {
+		return "", err
+	}
+
+	contentsRaw, err := fsReadFile(cg, path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(contentsRaw)), nil
+}
+
+// ListCgroups returns cg and all cgroups below it in the hierarchy of the
+// given controller, sorted by path so that parents come before their
+// children.
+func ListCgroups(cg Cgroup, controller string) ([]Cgroup, error) {
+	base, err := GetCgroupPath(cg, controller, "")
+	if err != nil {
+		return nil, err
+	}
+
+	cgroups := make([]Cgroup, 0)
+
+	err = fsWalkDirs(cg, base, func(dir string) error {
+		rel, err := filepath.Rel(base, dir)
+		if err != nil {
+			return err
+		}
+
+		cgroups = append(cgroups, withCgroup(cg, path.Join("/", cg.Cgroup, filepath.ToSlash(rel))))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(cgroups, func(i, j int) bool {
+		return cgroups[i].Cgroup < cgroups[j].Cgroup
+	})
+
+	return cgroups, nil
+}
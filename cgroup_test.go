@@ -0,0 +1,73 @@
+package cgroups
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func fixtureCgroup(fsys fs.FS) Cgroup {
+	return Cgroup{FS: fsys, Cgroup: fixtures.Cgroup}
+}
+
+func TestListCgroups(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{"cpu/system.slice/foo.service", "cpu/system.slice/bar.service", "cpu/user.slice"} {
+		if err := os.MkdirAll(path.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(path.Join(root, "cpu/system.slice/cpu.stat"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cgroups, err := ListCgroups(Cgroup{Root: root, Cgroup: "/system.slice"}, ControllerCpu)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"/system.slice", "/system.slice/bar.service", "/system.slice/foo.service"}
+	if len(cgroups) != len(expected) {
+		t.Fatalf("%+v\n", cgroups)
+	}
+
+	for i := range expected {
+		if cgroups[i].Cgroup != expected[i] || cgroups[i].Root != root {
+			t.Errorf("%+v\n", cgroups[i])
+		}
+	}
+}
+
+func TestListCgroupsFixtures(t *testing.T) {
+	for name, fsys := range fixtures.All {
+		cgroups, err := ListCgroups(fixtureCgroup(fsys), ControllerCpu)
+
+		if err != nil || len(cgroups) != 2 {
+			t.Fatalf("%s: %+v %v\n", name, cgroups, err)
+		}
+
+		if cgroups[1].Cgroup != fixtures.ChildCgroup || cgroups[1].FS != fsys {
+			t.Errorf("%s: %+v\n", name, cgroups[1])
+		}
+	}
+}
+
+func TestFixturesUnified(t *testing.T) {
+	if isUnified(fixtureCgroup(fixtures.V1), ControllerCpu) || isUnified(fixtureCgroup(fixtures.Hybrid), ControllerCpu) {
+		t.Fail()
+	}
+
+	if !isUnified(fixtureCgroup(fixtures.V2), ControllerCpu) || !isUnified(fixtureCgroup(fixtures.Hybrid), ControllerUnified) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+const (
+	groupCpu    = "c"
+	groupMemory = "m"
+	groupDisk   = "d"
+	groupNet    = "n"
+)
+
+const (
+	kindPct = iota
+	kindBytes
+	kindRate
+)
+
+type column struct {
+	group      string
+	controller string
+	name       string
+	kind       int
+	rate       bool /* needs two samples */
+	value      func(stats cgroups.CgroupStats, delta cgroups.CgroupDeltaStats) float64
+}
+
+var columns = []column{
+	{groupCpu, cgroups.ControllerCpu, "usr%", kindPct, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return d.Cpu.UserUsagePct
+	}},
+	{groupCpu, cgroups.ControllerCpu, "sys%", kindPct, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return d.Cpu.SystemUsagePct
+	}},
+	{groupCpu, cgroups.ControllerCpu, "cpu%", kindPct, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return d.Cpu.UsagePct
+	}},
+	{groupCpu, cgroups.ControllerCpu, "thr%", kindPct, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return d.Cpu.ThrottledPct
+	}},
+	{groupMemory, cgroups.ControllerMemory, "mem", kindBytes, false, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(s.Memory.MemUsage)
+	}},
+	{groupMemory, cgroups.ControllerMemory, "wset", kindBytes, false, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(s.Memory.WorkingSet())
+	}},
+	{groupMemory, cgroups.ControllerMemory, "mem%", kindPct, false, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return s.Memory.UsagePct()
+	}},
+	{groupMemory, cgroups.ControllerMemory, "flt/s", kindRate, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Memory.PgFaultRate)
+	}},
+	{groupMemory, cgroups.ControllerMemory, "mflt/s", kindRate, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return d.Memory.PgMajFaultRate
+	}},
+	{groupDisk, cgroups.ControllerBlkio, "r/s", kindRate, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Blkio.IoRateRead)
+	}},
+	{groupDisk, cgroups.ControllerBlkio, "w/s", kindRate, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Blkio.IoRateWrite)
+	}},
+	{groupDisk, cgroups.ControllerBlkio, "rB/s", kindBytes, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Blkio.ByteRateRead)
+	}},
+	{groupDisk, cgroups.ControllerBlkio, "wB/s", kindBytes, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Blkio.ByteRateWrite)
+	}},
+	{groupDisk, cgroups.ControllerBlkio, "svc_us", kindRate, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Blkio.AvgServiceTimeNs) / 1000
+	}},
+	{groupNet, cgroups.ControllerNet, "rxB/s", kindBytes, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Net.RxByteRate)
+	}},
+	{groupNet, cgroups.ControllerNet, "txB/s", kindBytes, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Net.TxByteRate)
+	}},
+	{groupNet, cgroups.ControllerNet, "rxpk/s", kindRate, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Net.RxPacketRate)
+	}},
+	{groupNet, cgroups.ControllerNet, "txpk/s", kindRate, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return float64(d.Net.TxPacketRate)
+	}},
+	{groupNet, cgroups.ControllerNet, "drop/s", kindRate, true, func(s cgroups.CgroupStats, d cgroups.CgroupDeltaStats) float64 {
+		return d.Net.RxDropRate + d.Net.TxDropRate
+	}},
+}
+
+// valid reports whether the column can be computed: its controller has
+// to be readable in stats, and also in prev for rates.
+func (c column) valid(stats cgroups.CgroupStats, prev cgroups.CgroupStats) bool {
+	if _, failed := stats.Errors[c.controller]; failed {
+		return false
+	}
+
+	if _, failed := prev.Errors[c.controller]; c.rate && failed {
+		return false
+	}
+
+	return true
+}
+
+func (c column) text(v float64) string {
+	switch c.kind {
+	case kindPct:
+		return fmt.Sprintf("%.1f", v)
+	case kindBytes:
+		return formatBytes(v)
+	default:
+		if v < 10 && v != float64(int64(v)) {
+			return fmt.Sprintf("%.1f", v)
+		}
+
+		return fmt.Sprintf("%.0f", v)
+	}
+}
+
+func formatBytes(v float64) string {
+	units := []string{"B", "K", "M", "G", "T", "P"}
+
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%.0f%s", v, units[i])
+	}
+
+	return fmt.Sprintf("%.1f%s", v, units[i])
+}
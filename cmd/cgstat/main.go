@@ -0,0 +1,347 @@
+// Command cgstat prints the activity of a single cgroup once per interval,
+// in the style of vmstat(1) and iostat(1).
+//
+// Usage:
+//
+//	cgstat [-c] [-m] [-d] [-n] [-o text|csv|json] [-proc-root dir] [-sys-root dir] [-w file | -f file] target [interval [count]]
+//
+// The target is a cgroup path (starting with "/"), a PID or a container
+// ID. A PID or container ID is resolved separately in the hierarchy of
+// each controller, as on v1 they may differ. -c, -m, -d and -n select the CPU, memory, disk and network columns;
+// without any of them, all are shown. The interval defaults to one second
+// and the count to running until interrupted.
+//
+// With -w, every sample is also appended to a recording file. With -f,
+// the samples of the target cgroup (which has to be given as a path) are
+// read from a recording instead, ignoring the interval.
+//
+// To monitor the host from a container, point -proc-root and -sys-root at
+// where its /proc and /sys are mounted, e.g. /host/proc and /host/sys.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+type options struct {
+	groups     string
+	output     string
+	interval   time.Duration
+	count      int
+	headerRows int
+
+	record string
+	replay string
+}
+
+func main() {
+	var opts options
+	var host cgroups.Host
+
+	cpu := flag.Bool("c", false, "show CPU columns")
+	mem := flag.Bool("m", false, "show memory columns")
+	disk := flag.Bool("d", false, "show disk columns")
+	net := flag.Bool("n", false, "show network columns")
+	flag.StringVar(&opts.output, "o", "text", "output format: text, csv or json")
+	flag.StringVar(&host.Root, "root", "", "cgroup filesystem root (default <sys-root>/fs/cgroup)")
+	flag.StringVar(&host.ProcRoot, "proc-root", cgroups.DefaultProcRoot, "proc filesystem root, e.g. /host/proc in a container")
+	flag.StringVar(&host.SysRoot, "sys-root", cgroups.DefaultSysRoot, "sys filesystem root, e.g. /host/sys in a container")
+	flag.StringVar(&opts.record, "w", "", "append samples to a recording file")
+	flag.StringVar(&opts.replay, "f", "", "read samples from a recording file")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: cgstat [flags] cgroup|pid|container-id [interval [count]]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	for _, g := range []struct {
+		set   bool
+		group string
+	}{{*cpu, groupCpu}, {*mem, groupMemory}, {*disk, groupDisk}, {*net, groupNet}} {
+		if g.set {
+			opts.groups += g.group
+		}
+	}
+
+	if opts.groups == "" {
+		opts.groups = groupCpu + groupMemory + groupDisk + groupNet
+	}
+
+	if opts.output != "text" && opts.output != "csv" && opts.output != "json" {
+		usageError("unknown output format %q", opts.output)
+	}
+
+	if flag.NArg() < 1 || flag.NArg() > 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts.interval = time.Second
+	if flag.NArg() > 1 {
+		secs, err := strconv.ParseFloat(flag.Arg(1), 64)
+		if err != nil || secs <= 0 {
+			usageError("invalid interval %q", flag.Arg(1))
+		}
+
+		opts.interval = time.Duration(secs * float64(time.Second))
+	}
+
+	if flag.NArg() > 2 {
+		count, err := strconv.Atoi(flag.Arg(2))
+		if err != nil || count < 0 {
+			usageError("invalid count %q", flag.Arg(2))
+		}
+
+		opts.count = count
+	}
+
+	if opts.record != "" && opts.replay != "" {
+		usageError("-w and -f are mutually exclusive")
+	}
+
+	if opts.replay != "" && !strings.HasPrefix(flag.Arg(0), "/") {
+		usageError("a recording can only be replayed for a cgroup path")
+	}
+
+	opts.headerRows = 20
+
+	cgs, err := resolveTargets(host, flag.Arg(0), opts.groups)
+	if err == nil && opts.replay != "" {
+		err = replay(cgs.primary(opts.groups), opts)
+	} else if err == nil {
+		err = run(cgs, opts)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cgstat: %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func usageError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "cgstat: "+format+"\n", args...)
+	os.Exit(2)
+}
+
+// targets holds the cgroup each column group is read from.
+type targets map[string]cgroups.Cgroup
+
+// The hierarchy each column group is resolved in. The network stats are
+// read through the processes of the cgroup, found in the cpu hierarchy.
+var groupControllers = map[string]string{
+	groupCpu:    cgroups.ControllerCpu,
+	groupMemory: cgroups.ControllerMemory,
+	groupDisk:   cgroups.ControllerBlkio,
+	groupNet:    cgroups.ControllerCpu,
+}
+
+// resolveTargets resolves the target once per controller of the groups.
+func resolveTargets(host cgroups.Host, target string, groups string) (targets, error) {
+	cgs := make(targets)
+	byController := make(map[string]cgroups.Cgroup)
+
+	for _, g := range strings.Split(groups, "") {
+		controller := groupControllers[g]
+
+		cg, ok := byController[controller]
+		if !ok {
+			var err error
+
+			cg, err = resolveTarget(host, target, controller)
+			if err != nil {
+				return nil, err
+			}
+
+			byController[controller] = cg
+		}
+
+		cgs[g] = cg
+	}
+
+	return cgs, nil
+}
+
+// primary is the cgroup of the first group, which samples are recorded
+// and replayed under.
+func (cgs targets) primary(groups string) cgroups.Cgroup {
+	return cgs[groups[:1]]
+}
+
+// sample reads the stats of all groups, once per distinct cgroup, and
+// merges them into one snapshot.
+func (cgs targets) sample(groups string) cgroups.CgroupStats {
+	byCgroup := make(map[string]string)
+	for _, g := range strings.Split(groups, "") {
+		byCgroup[cgs[g].Cgroup] += g
+	}
+
+	merged := cgroups.GetAllStats(cgs.primary(groups), statsOptions(byCgroup[cgs.primary(groups).Cgroup]))
+	merged.Options = statsOptions(groups)
+
+	for cgroup, cgGroups := range byCgroup {
+		if cgroup == merged.Cgroup.Cgroup {
+			continue
+		}
+
+		stats := cgroups.GetAllStats(cgs[cgGroups[:1]], statsOptions(cgGroups))
+
+		for _, g := range strings.Split(cgGroups, "") {
+			switch g {
+			case groupCpu:
+				merged.Cpu = stats.Cpu
+			case groupMemory:
+				merged.Memory = stats.Memory
+			case groupDisk:
+				merged.Blkio = stats.Blkio
+			case groupNet:
+				merged.Net = stats.Net
+			}
+		}
+
+		for controller, err := range stats.Errors {
+			merged.Errors[controller] = err
+		}
+	}
+
+	return merged
+}
+
+func statsOptions(groups string) cgroups.StatsOptions {
+	return cgroups.StatsOptions{
+		SkipCpu:    !strings.Contains(groups, groupCpu),
+		SkipMemory: !strings.Contains(groups, groupMemory),
+		SkipBlkio:  !strings.Contains(groups, groupDisk),
+		SkipNet:    !strings.Contains(groups, groupNet),
+		SkipPids:   true,
+		SkipPsi:    true,
+	}
+}
+
+// resolveTarget turns a cgroup path, PID or container ID into a Cgroup.
+func resolveTarget(host cgroups.Host, target string, controller string) (cgroups.Cgroup, error) {
+	if strings.HasPrefix(target, "/") {
+		return host.Cgroup(target), nil
+	}
+
+	if pid, err := strconv.Atoi(target); err == nil {
+		return host.GetPidCgroup(pid, controller)
+	}
+
+	return host.FindContainerCgroup(target, controller)
+}
+
+func run(cgs targets, opts options) error {
+	w := newWriter(os.Stdout, opts)
+
+	var recorder *cgroups.Recorder
+	if opts.record != "" {
+		var err error
+
+		recorder, err = cgroups.OpenRecorder(opts.record, nil)
+		if err != nil {
+			return err
+		}
+		defer recorder.Close()
+	}
+
+	prev := cgs.sample(opts.groups)
+	if err := checkStats(prev, len(opts.groups)); err != nil {
+		return err
+	}
+
+	if recorder != nil {
+		if err := recorder.Write(prev); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	for i := 0; opts.count == 0 || i < opts.count; i++ {
+		<-ticker.C
+
+		stats := cgs.sample(opts.groups)
+
+		if recorder != nil {
+			if err := recorder.Write(stats); err != nil {
+				return err
+			}
+		}
+
+		if err := w.write(stats, prev); err != nil {
+			return err
+		}
+
+		prev = stats
+	}
+
+	return nil
+}
+
+// replay prints the samples of cg found in the recording file, as if they
+// had been read live.
+func replay(cg cgroups.Cgroup, opts options) error {
+	player, err := cgroups.OpenPlayer(opts.replay)
+	if err != nil {
+		return err
+	}
+	defer player.Close()
+
+	w := newWriter(os.Stdout, opts)
+
+	var prev *cgroups.CgroupStats
+	for i := 0; opts.count == 0 || i < opts.count; {
+		stats, err := player.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if stats.Cgroup.Cgroup != cg.Cgroup {
+			continue
+		}
+
+		if prev != nil {
+			if err := w.write(stats, *prev); err != nil {
+				return err
+			}
+			i++
+		}
+
+		prev = &stats
+	}
+
+	if prev == nil {
+		return cgroups.ErrNoCgroup
+	}
+
+	return nil
+}
+
+// checkStats fails if none of the requested controllers could be found
+// for the cgroup, most likely because it does not exist.
+func checkStats(stats cgroups.CgroupStats, requested int) error {
+	if len(stats.Errors) < requested {
+		return nil
+	}
+
+	for _, err := range stats.Errors {
+		if err != cgroups.ErrNoCgroup {
+			return nil
+		}
+	}
+
+	return cgroups.ErrNoCgroup
+}
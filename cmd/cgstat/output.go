@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+// jsonLine is one line of -o json output: the raw snapshot and the delta
+// to the previous one, both in the library's JSON schema.
+type jsonLine struct {
+	Stats cgroups.CgroupStats      `json:"stats"`
+	Delta cgroups.CgroupDeltaStats `json:"delta"`
+}
+
+type writer struct {
+	w       io.Writer
+	opts    options
+	columns []column
+	lines   int
+
+	csv *csv.Writer
+}
+
+func newWriter(w io.Writer, opts options) *writer {
+	wr := &writer{
+		w:    w,
+		opts: opts,
+	}
+
+	for _, col := range columns {
+		if strings.Contains(opts.groups, col.group) {
+			wr.columns = append(wr.columns, col)
+		}
+	}
+
+	if opts.output == "csv" {
+		wr.csv = csv.NewWriter(w)
+	}
+
+	return wr
+}
+
+func (wr *writer) write(stats cgroups.CgroupStats, prev cgroups.CgroupStats) error {
+	delta := stats.Delta(prev)
+
+	switch wr.opts.output {
+	case "json":
+		data, err := json.Marshal(jsonLine{Stats: stats, Delta: delta})
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(wr.w, "%s\n", data)
+		return err
+
+	case "csv":
+		if wr.lines == 0 {
+			header := []string{"time"}
+			for _, col := range wr.columns {
+				header = append(header, col.name)
+			}
+
+			wr.csv.Write(header)
+		}
+
+		record := []string{stats.SampleTime.Format(time.RFC3339Nano)}
+		for _, col := range wr.columns {
+			value := ""
+			if col.valid(stats, prev) {
+				value = strconv.FormatFloat(col.value(stats, delta), 'f', -1, 64)
+			}
+
+			record = append(record, value)
+		}
+
+		wr.csv.Write(record)
+		wr.lines++
+		wr.csv.Flush()
+
+		return wr.csv.Error()
+
+	default:
+		var line strings.Builder
+
+		if wr.opts.headerRows > 0 && wr.lines%wr.opts.headerRows == 0 {
+			fmt.Fprintf(&line, "%-8s", "time")
+			for _, col := range wr.columns {
+				fmt.Fprintf(&line, " %7s", col.name)
+			}
+			line.WriteString("\n")
+		}
+
+		fmt.Fprintf(&line, "%-8s", stats.SampleTime.Format("15:04:05"))
+		for _, col := range wr.columns {
+			value := "-"
+			if col.valid(stats, prev) {
+				value = col.text(col.value(stats, delta))
+			}
+
+			fmt.Fprintf(&line, " %7s", value)
+		}
+		line.WriteString("\n")
+
+		wr.lines++
+
+		_, err := io.WriteString(wr.w, line.String())
+		return err
+	}
+}
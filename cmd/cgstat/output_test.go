@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	cgroups "github.com/bwalex/go-cgroups"
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func testStats() (cgroups.CgroupStats, cgroups.CgroupStats) {
+	now := time.Unix(1500000000, 0)
+
+	prev := cgroups.CgroupStats{
+		Cgroup:     cgroups.Cgroup{Cgroup: "/system.slice"},
+		SampleTime: now,
+		Cpu:        cgroups.CpuStat{UserTimeUs: 0},
+		Memory:     cgroups.MemoryStat{MemUsage: 2048, PgFault: 10},
+		Errors:     map[string]error{cgroups.ControllerNet: cgroups.ErrNoStat},
+	}
+
+	stats := prev
+	stats.SampleTime = now.Add(time.Second)
+	stats.Cpu = cgroups.CpuStat{UserTimeUs: 250000}
+	stats.Memory = cgroups.MemoryStat{MemUsage: 4096, PgFault: 30}
+
+	return stats, prev
+}
+
+func TestTextOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	stats, prev := testStats()
+	wr := newWriter(&buf, options{groups: groupCpu + groupMemory + groupNet, output: "text", headerRows: 20})
+
+	if err := wr.write(stats, prev); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("%s", buf.String())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal()
+	}
+
+	header := strings.Fields(lines[0])
+	fields := strings.Fields(lines[1])
+	if len(header) != len(fields) || header[3] != "cpu%" || fields[3] != "25.0" || fields[5] != "4.0K" || fields[8] != "20" || fields[10] != "-" {
+		t.Errorf("%v\n%v\n", header, fields)
+	}
+}
+
+func TestCsvOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	stats, prev := testStats()
+	wr := newWriter(&buf, options{groups: groupMemory + groupNet, output: "csv"})
+
+	wr.write(stats, prev)
+	wr.write(stats, prev)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "time,mem,wset,mem%,flt/s,mflt/s,rxB/s") {
+		t.Fatalf("%s", buf.String())
+	}
+
+	if !strings.HasPrefix(lines[1], "2017-07-1") || !strings.Contains(lines[1], ",4096,4096,0,20,0,,") {
+		t.Errorf("%s", lines[1])
+	}
+}
+
+func TestJsonOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	stats, prev := testStats()
+	wr := newWriter(&buf, options{groups: groupCpu, output: "json"})
+	wr.write(stats, prev)
+
+	var line jsonLine
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+
+	if line.Delta.Cpu.UsagePct != 25 || line.Stats.Memory.MemUsage != 4096 {
+		t.Errorf("%+v\n", line)
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	host := cgroups.Host{FS: fixtures.V2}
+
+	for _, target := range []string{fixtures.Cgroup, "1234"} {
+		cg, err := resolveTarget(host, target, cgroups.ControllerCpu)
+		if err != nil || cg.Cgroup != fixtures.Cgroup || cg.FS == nil {
+			t.Errorf("%s: %+v %v\n", target, cg, err)
+		}
+	}
+
+	if _, err := resolveTarget(host, "foo", cgroups.ControllerCpu); err != cgroups.ErrInvalidContainer {
+		t.Error(err)
+	}
+}
+
+// overlayFS serves the files in place of those of base.
+type overlayFS struct {
+	files fstest.MapFS
+	base  fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if _, ok := o.files[name]; ok {
+		return o.files.Open(name)
+	}
+
+	return o.base.Open(name)
+}
+
+func TestResolveTargetsPerController(t *testing.T) {
+	// The process is in different cgroups in the cpu and memory hierarchies
+	host := cgroups.Host{FS: overlayFS{fstest.MapFS{
+		"proc/1234/cgroup": {Data: []byte("11:memory:" + fixtures.ChildCgroup + "\n4:cpu,cpuacct:" + fixtures.Cgroup + "\n")},
+		"sys/fs/cgroup/memory" + fixtures.ChildCgroup + "/memory.usage_in_bytes": {Data: []byte("1048576\n")},
+		"sys/fs/cgroup/memory" + fixtures.ChildCgroup + "/memory.stat":           {Data: []byte("cache 0\nrss 1048576\n")},
+	}, fixtures.V1}}
+
+	cgs, err := resolveTargets(host, "1234", groupCpu+groupMemory)
+	if err != nil || cgs[groupCpu].Cgroup != fixtures.Cgroup || cgs[groupMemory].Cgroup != fixtures.ChildCgroup {
+		t.Fatalf("%+v %v\n", cgs, err)
+	}
+
+	stats := cgs.sample(groupCpu + groupMemory)
+	memory, _ := cgroups.GetMemoryStats(cgs[groupMemory])
+	cpu, _ := cgroups.GetCpuStats(cgs[groupCpu])
+
+	if len(stats.Errors) != 0 || stats.Memory.MemUsage != 1048576 || stats.Memory.MemUsage != memory.MemUsage || cpu.UsageNs == 0 || stats.Cpu.UsageNs != cpu.UsageNs {
+		t.Errorf("%+v\n", stats)
+	}
+
+	if stats.Collected(cgroups.ControllerNet) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,212 @@
+// Command cgtop shows a refreshing table of the busiest cgroups, like
+// top(1) does for processes. Unlike systemd-cgtop it also shows network
+// traffic and CPU throttling.
+//
+// Usage:
+//
+//	cgtop [-d interval] [-n count] [-b] [-flat] [-s key] [-depth n] [-proc-root dir] [-sys-root dir] [-w file | -f file] [cgroup]
+//
+// Sort keys are cpu, mem, ws (working set), io, net, thr (throttled) and
+// path. In interactive mode the first letter of a sort key changes the
+// sort order, r reverses it, f toggles between the tree and flat views
+// and q quits.
+//
+// With -w, every sample is also appended to a recording file, which can
+// later be replayed with -f instead of reading the live system. In batch
+// mode a recording is replayed as fast as possible.
+//
+// To monitor the host from a container, point -proc-root and -sys-root at
+// where its /proc and /sys are mounted, e.g. /host/proc and /host/sys.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+type options struct {
+	interval time.Duration
+	count    int
+	batch    bool
+	flat     bool
+	sortKey  string
+	reverse  bool
+	depth    int
+
+	record string
+	replay string
+}
+
+func main() {
+	var opts options
+	var host cgroups.Host
+
+	flag.DurationVar(&opts.interval, "d", 2*time.Second, "refresh interval")
+	flag.IntVar(&opts.count, "n", 0, "number of refreshes before exiting, 0 to run until interrupted")
+	flag.BoolVar(&opts.batch, "b", false, "batch mode: print each table instead of refreshing the screen")
+	flag.BoolVar(&opts.flat, "flat", false, "show a flat list instead of a tree")
+	flag.StringVar(&opts.sortKey, "s", "cpu", "sort key: cpu, mem, ws, io, net, thr or path")
+	flag.BoolVar(&opts.reverse, "r", false, "reverse the sort order")
+	flag.IntVar(&opts.depth, "depth", 0, "maximum depth below the starting cgroup, 0 for no limit")
+	flag.StringVar(&host.Root, "root", "", "cgroup filesystem root (default <sys-root>/fs/cgroup)")
+	flag.StringVar(&host.ProcRoot, "proc-root", cgroups.DefaultProcRoot, "proc filesystem root, e.g. /host/proc in a container")
+	flag.StringVar(&host.SysRoot, "sys-root", cgroups.DefaultSysRoot, "sys filesystem root, e.g. /host/sys in a container")
+	flag.StringVar(&opts.record, "w", "", "append samples to a recording file")
+	flag.StringVar(&opts.replay, "f", "", "replay a recording file instead of sampling")
+	flag.Parse()
+
+	if opts.record != "" && opts.replay != "" {
+		fmt.Fprintf(os.Stderr, "cgtop: -w and -f are mutually exclusive\n")
+		os.Exit(2)
+	}
+
+	if opts.interval <= 0 {
+		fmt.Fprintf(os.Stderr, "cgtop: invalid interval %v\n", opts.interval)
+		os.Exit(2)
+	}
+
+	if _, ok := sortKeys[opts.sortKey]; !ok {
+		fmt.Fprintf(os.Stderr, "cgtop: unknown sort key %q\n", opts.sortKey)
+		os.Exit(2)
+	}
+
+	start := host.Cgroup("/")
+	if flag.NArg() > 0 {
+		start.Cgroup = flag.Arg(0)
+	}
+
+	if err := run(start, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "cgtop: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(start cgroups.Cgroup, opts options) error {
+	keys := make(chan byte)
+	interactive := false
+
+	if !opts.batch {
+		restore, err := makeRaw(int(os.Stdin.Fd()))
+		if err == nil {
+			interactive = true
+			defer restore()
+
+			// Restore the terminal on ^C as well
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigs
+				restore()
+				os.Exit(1)
+			}()
+
+			go readKeys(keys)
+		}
+	}
+
+	top := newTop(start, opts)
+
+	if opts.record != "" {
+		recorder, err := cgroups.OpenRecorder(opts.record, nil)
+		if err != nil {
+			return err
+		}
+		defer recorder.Close()
+
+		top.recorder = recorder
+	}
+
+	if opts.replay != "" {
+		player, err := cgroups.OpenPlayer(opts.replay)
+		if err != nil {
+			return err
+		}
+		defer player.Close()
+
+		top.player = player
+	}
+
+	if err := top.sample(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	tick := ticker.C
+	if top.player != nil && opts.batch {
+		fast := make(chan time.Time)
+		close(fast)
+		tick = fast
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+
+	for i := 0; opts.count == 0 || i < opts.count; {
+		select {
+		case <-tick:
+			err := top.sample()
+			if err == io.EOF && interactive {
+				// Keep showing the end of the recording until q
+				tick = nil
+				continue
+			}
+
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+			i++
+
+		case key := <-keys:
+			if key == 'q' {
+				return nil
+			}
+
+			if !top.handleKey(key) || top.rows == nil {
+				continue
+			}
+		}
+
+		if opts.batch {
+			top.render(out, 0)
+			fmt.Fprintln(out)
+		} else {
+			_, height := termSize(int(os.Stdout.Fd()))
+			fmt.Fprint(out, "\033[H\033[2J")
+			top.render(out, height-2)
+		}
+
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readKeys(keys chan<- byte) {
+	buf := make([]byte, 1)
+
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+
+		if n == 1 {
+			keys <- buf[0]
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"errors"
+)
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw terminal mode not supported")
+}
+
+func termSize(fd int) (int, int) {
+	return 80, 24
+}
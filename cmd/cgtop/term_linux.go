@@ -0,0 +1,53 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// makeRaw disables line buffering and echo on the terminal, so that keys
+// can be read as they are pressed. Signals are left enabled.
+func makeRaw(fd int) (func(), error) {
+	var old syscall.Termios
+
+	if err := ioctl(fd, syscall.TCGETS, unsafe.Pointer(&old)); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := ioctl(fd, syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ioctl(fd, syscall.TCSETS, unsafe.Pointer(&old))
+	}, nil
+}
+
+func termSize(fd int) (int, int) {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil || ws.Row == 0 {
+		return 80, 24
+	}
+
+	return int(ws.Col), int(ws.Row)
+}
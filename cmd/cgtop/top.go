@@ -0,0 +1,386 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+const (
+	colCpu = iota
+	colMem
+	colWorkingSet
+	colIoRead
+	colIoWrite
+	colNetRx
+	colNetTx
+	colThrottled
+	numColumns
+)
+
+type column struct {
+	header string
+	format func(float64) string
+}
+
+var columns = [numColumns]column{
+	colCpu:        {"CPU%", formatPct},
+	colMem:        {"Memory", formatBytes},
+	colWorkingSet: {"WorkSet", formatBytes},
+	colIoRead:     {"IO-Rd/s", formatBytes},
+	colIoWrite:    {"IO-Wr/s", formatBytes},
+	colNetRx:      {"Net-Rx/s", formatBytes},
+	colNetTx:      {"Net-Tx/s", formatBytes},
+	colThrottled:  {"Thr%", formatPct},
+}
+
+// row is one cgroup in the table. Values that could not be read, or
+// rates without a previous sample, are not Valid and shown as "-".
+type row struct {
+	Path   string
+	Depth  int
+	Values [numColumns]float64
+	Valid  [numColumns]bool
+}
+
+func (r *row) set(col int, v float64) {
+	r.Values[col] = v
+	r.Valid[col] = true
+}
+
+func (r row) sum(cols ...int) float64 {
+	sum := -1.0
+
+	for _, col := range cols {
+		if r.Valid[col] {
+			sum = r.Values[col] + maxFloat(sum, 0)
+		}
+	}
+
+	return sum
+}
+
+// Invalid values sort below zero
+var sortKeys = map[string]func(r row) float64{
+	"cpu":  func(r row) float64 { return r.sum(colCpu) },
+	"mem":  func(r row) float64 { return r.sum(colMem) },
+	"ws":   func(r row) float64 { return r.sum(colWorkingSet) },
+	"io":   func(r row) float64 { return r.sum(colIoRead, colIoWrite) },
+	"net":  func(r row) float64 { return r.sum(colNetRx, colNetTx) },
+	"thr":  func(r row) float64 { return r.sum(colThrottled) },
+	"path": nil,
+}
+
+type top struct {
+	start cgroups.Cgroup
+	opts  options
+
+	recorder *cgroups.Recorder
+	player   *cgroups.Player
+
+	prev map[string]cgroups.CgroupStats
+	rows []row
+	time time.Time
+}
+
+func newTop(start cgroups.Cgroup, opts options) *top {
+	return &top{
+		start: start,
+		opts:  opts,
+	}
+}
+
+// sample reads all cgroups below the starting one, or the next batch of
+// the recording being replayed, and computes the rows from the deltas to
+// the previous sample.
+func (t *top) sample() error {
+	if t.player != nil {
+		batch, err := t.player.NextBatch()
+		if err != nil {
+			return err
+		}
+
+		snapshot := make([]cgroups.CgroupStats, 0, len(batch))
+		for _, stats := range batch {
+			if t.shown(stats.Cgroup.Cgroup) {
+				snapshot = append(snapshot, stats)
+			}
+		}
+
+		t.update(snapshot)
+
+		return nil
+	}
+
+	cgs, err := cgroups.ListCgroups(t.start, cgroups.ControllerCpu)
+	if err != nil {
+		return err
+	}
+
+	statsOpts := cgroups.StatsOptions{SkipPids: true, SkipPsi: true}
+	snapshot := make([]cgroups.CgroupStats, 0, len(cgs))
+
+	for _, cg := range cgs {
+		if t.shown(cg.Cgroup) {
+			snapshot = append(snapshot, cgroups.GetAllStats(cg, statsOpts))
+		}
+	}
+
+	if t.recorder != nil {
+		if err := t.recorder.Write(snapshot...); err != nil {
+			return err
+		}
+	}
+
+	t.update(snapshot)
+
+	return nil
+}
+
+// shown reports whether a cgroup is the starting one or below it, within
+// the maximum depth.
+func (t *top) shown(cg string) bool {
+	start := strings.TrimSuffix(t.start.Cgroup, "/")
+	if cg != t.start.Cgroup && !strings.HasPrefix(cg, start+"/") {
+		return false
+	}
+
+	return t.opts.depth == 0 || cgroupDepth(t.start.Cgroup, cg) <= t.opts.depth
+}
+
+// update replaces the rows with those computed from snapshot. The first
+// call only fills in the memory columns.
+func (t *top) update(snapshot []cgroups.CgroupStats) {
+	cur := make(map[string]cgroups.CgroupStats, len(snapshot))
+	rows := make([]row, 0, len(snapshot))
+
+	for _, stats := range snapshot {
+		cur[stats.Cgroup.Cgroup] = stats
+
+		prev, ok := t.prev[stats.Cgroup.Cgroup]
+		rows = append(rows, newRow(stats, prev, ok))
+
+		if stats.SampleTime.After(t.time) {
+			t.time = stats.SampleTime
+		}
+	}
+
+	t.prev = cur
+	t.rows = rows
+}
+
+func newRow(stats cgroups.CgroupStats, prev cgroups.CgroupStats, hasPrev bool) row {
+	r := row{Path: stats.Cgroup.Cgroup}
+
+	if readOk(stats, cgroups.ControllerMemory) {
+		r.set(colMem, float64(stats.Memory.MemUsage))
+		r.set(colWorkingSet, float64(stats.Memory.WorkingSet()))
+	}
+
+	if !hasPrev || !stats.SampleTime.After(prev.SampleTime) {
+		return r
+	}
+
+	delta := stats.Delta(prev)
+
+	if readOk(stats, cgroups.ControllerCpu) && readOk(prev, cgroups.ControllerCpu) {
+		r.set(colCpu, delta.Cpu.UsagePct)
+		r.set(colThrottled, delta.Cpu.ThrottledPct)
+	}
+
+	if readOk(stats, cgroups.ControllerBlkio) && readOk(prev, cgroups.ControllerBlkio) {
+		r.set(colIoRead, float64(delta.Blkio.ByteRateRead))
+		r.set(colIoWrite, float64(delta.Blkio.ByteRateWrite))
+	}
+
+	if readOk(stats, cgroups.ControllerNet) && readOk(prev, cgroups.ControllerNet) {
+		r.set(colNetRx, float64(delta.Net.RxByteRate))
+		r.set(colNetTx, float64(delta.Net.TxByteRate))
+	}
+
+	return r
+}
+
+func readOk(stats cgroups.CgroupStats, controller string) bool {
+	_, failed := stats.Errors[controller]
+	return !failed
+}
+
+func (t *top) handleKey(key byte) bool {
+	switch key {
+	case 'f':
+		t.opts.flat = !t.opts.flat
+	case 'r':
+		t.opts.reverse = !t.opts.reverse
+	default:
+		for name := range sortKeys {
+			if name[0] == key {
+				t.opts.sortKey = name
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// ordered returns the rows in display order.
+func (t *top) ordered() []row {
+	if t.opts.flat {
+		return sortRows(t.rows, t.opts.sortKey, t.opts.reverse)
+	}
+
+	return treeRows(t.rows, t.opts.sortKey, t.opts.reverse)
+}
+
+// render writes the table, limited to maxRows rows if maxRows > 0.
+func (t *top) render(w io.Writer, maxRows int) {
+	view := "tree"
+	if t.opts.flat {
+		view = "flat"
+	}
+
+	order := ""
+	if t.opts.reverse {
+		order = ", reversed"
+	}
+
+	fmt.Fprintf(w, "cgtop - %s - sort: %s%s - view: %s\n", t.time.Format("15:04:05"), t.opts.sortKey, order, view)
+
+	for _, col := range columns {
+		fmt.Fprintf(w, "%9s ", col.header)
+	}
+	fmt.Fprintln(w, " Cgroup")
+
+	for i, r := range t.ordered() {
+		if maxRows > 0 && i >= maxRows-1 {
+			break
+		}
+
+		for col := range columns {
+			value := "-"
+			if r.Valid[col] {
+				value = columns[col].format(r.Values[col])
+			}
+
+			fmt.Fprintf(w, "%9s ", value)
+		}
+
+		if t.opts.flat {
+			fmt.Fprintf(w, " %s\n", r.Path)
+		} else {
+			fmt.Fprintf(w, " %s%s\n", strings.Repeat("  ", r.Depth), path.Base(r.Path))
+		}
+	}
+}
+
+func lessFunc(key string, reverse bool) func(a, b row) bool {
+	keyFn := sortKeys[key]
+
+	return func(a, b row) bool {
+		// Ascending by path, descending by everything else
+		less := a.Path < b.Path
+		if keyFn != nil && keyFn(a) != keyFn(b) {
+			less = keyFn(a) > keyFn(b)
+		}
+
+		if reverse {
+			return !less
+		}
+
+		return less
+	}
+}
+
+func sortRows(rows []row, key string, reverse bool) []row {
+	sorted := append([]row(nil), rows...)
+	less := lessFunc(key, reverse)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+
+	for i := range sorted {
+		sorted[i].Depth = 0
+	}
+
+	return sorted
+}
+
+// treeRows orders the rows depth-first, with siblings sorted by key. A
+// row whose parent is not in rows is shown at the top level.
+func treeRows(rows []row, key string, reverse bool) []row {
+	children := make(map[string][]row)
+	present := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		present[r.Path] = true
+	}
+
+	roots := make([]row, 0)
+	for _, r := range rows {
+		parent := path.Dir(r.Path)
+		if parent == r.Path || !present[parent] {
+			roots = append(roots, r)
+		} else {
+			children[parent] = append(children[parent], r)
+		}
+	}
+
+	ordered := make([]row, 0, len(rows))
+
+	var walk func(level []row, depth int)
+	walk = func(level []row, depth int) {
+		for _, r := range sortRows(level, key, reverse) {
+			r.Depth = depth
+			ordered = append(ordered, r)
+			walk(children[r.Path], depth+1)
+		}
+	}
+	walk(roots, 0)
+
+	return ordered
+}
+
+func cgroupDepth(start string, cg string) int {
+	rel := strings.Trim(strings.TrimPrefix(cg, start), "/")
+	if rel == "" {
+		return 0
+	}
+
+	return strings.Count(rel, "/") + 1
+}
+
+func formatPct(v float64) string {
+	return fmt.Sprintf("%.1f", v)
+}
+
+func formatBytes(v float64) string {
+	units := []string{"B", "K", "M", "G", "T", "P"}
+
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%.0f%s", v, units[i])
+	}
+
+	return fmt.Sprintf("%.1f%s", v, units[i])
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
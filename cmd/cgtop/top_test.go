@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+func testRow(p string, cpu float64) row {
+	r := row{Path: p}
+	r.set(colCpu, cpu)
+
+	return r
+}
+
+func TestTreeRows(t *testing.T) {
+	rows := []row{
+		testRow("/", 50),
+		testRow("/system.slice", 10),
+		testRow("/system.slice/a.service", 1),
+		testRow("/system.slice/b.service", 9),
+		testRow("/user.slice", 40),
+	}
+
+	expected := []string{"/", "/user.slice", "/system.slice", "/system.slice/b.service", "/system.slice/a.service"}
+	depths := []int{0, 1, 1, 2, 2}
+
+	ordered := treeRows(rows, "cpu", false)
+	for i := range expected {
+		if ordered[i].Path != expected[i] || ordered[i].Depth != depths[i] {
+			t.Errorf("%d: %+v\n", i, ordered[i])
+		}
+	}
+
+	ordered = sortRows(rows, "path", true)
+	if ordered[0].Path != "/user.slice" || ordered[4].Path != "/" {
+		t.Fail()
+	}
+}
+
+func TestRender(t *testing.T) {
+	now := time.Now()
+	cg := cgroups.Cgroup{Cgroup: "/system.slice"}
+
+	prev := cgroups.CgroupStats{
+		Cgroup:     cg,
+		SampleTime: now,
+		Cpu:        cgroups.CpuStat{UserTimeUs: 0},
+		Memory:     cgroups.MemoryStat{MemUsage: 2048},
+		Errors:     map[string]error{},
+	}
+
+	cur := prev
+	cur.SampleTime = now.Add(time.Second)
+	cur.Cpu = cgroups.CpuStat{UserTimeUs: 500000}
+	cur.Errors = map[string]error{cgroups.ControllerNet: cgroups.ErrNoStat}
+
+	top := newTop(cg, options{sortKey: "cpu"})
+	top.update([]cgroups.CgroupStats{prev})
+	top.update([]cgroups.CgroupStats{cur})
+
+	var buf bytes.Buffer
+	top.render(&buf, 0)
+	t.Logf("%s", buf.String())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatal()
+	}
+
+	fields := strings.Fields(lines[2])
+	if fields[0] != "50.0" || fields[1] != "2.0K" || fields[5] != "-" || fields[8] != "system.slice" {
+		t.Errorf("%v\n", fields)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	if formatBytes(512) != "512B" || formatBytes(1536) != "1.5K" || formatBytes(3*1024*1024*1024) != "3.0G" {
+		t.Fail()
+	}
+}
@@ -2,7 +2,6 @@ package cgroups
 
 import (
 	"bufio"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -10,28 +9,65 @@ import (
 
 type CpuStat struct {
 	// From cpuacct.stat
-	UserTimeUs       uint64 /* in microseconds */
-	SystemTimeUs     uint64 /* in microseconds */
+	UserTimeUs   uint64 `json:"user_time_us,omitempty"`   /* in microseconds */
+	SystemTimeUs uint64 `json:"system_time_us,omitempty"` /* in microseconds */
 
 	// From cpu.stat
-	ThrottledTimeUs  uint64 /* in microseconds */
-	Periods          uint64
-	ThrottledPeriods uint64
+	ThrottledTimeUs  uint64 `json:"throttled_time_us,omitempty"` /* in microseconds */
+	Periods          uint64 `json:"periods,omitempty"`
+	ThrottledPeriods uint64 `json:"throttled_periods,omitempty"`
+	Bursts           uint64 `json:"bursts,omitempty"`
+	BurstTimeUs      uint64 `json:"burst_time_us,omitempty"` /* in microseconds */
+
+	// From cpuacct.usage, cpuacct.usage_percpu and cpuacct.usage_all
+	// (or usage_usec in cpu.stat on v2, which has no per-CPU data)
+	UsageNs        uint64   `json:"usage_ns,omitempty"`          /* in nanoseconds */
+	PerCpuUsageNs  []uint64 `json:"per_cpu_usage_ns,omitempty"`  /* in nanoseconds */
+	PerCpuUserNs   []uint64 `json:"per_cpu_user_ns,omitempty"`   /* in nanoseconds */
+	PerCpuSystemNs []uint64 `json:"per_cpu_system_ns,omitempty"` /* in nanoseconds */
+
+	// From cpu.cfs_quota_us and cpu.cfs_period_us (or cpu.max on v2),
+	// cpuset.effective_cpus and /sys/devices/system/cpu/online
+	QuotaUs    uint64 `json:"quota_us,omitempty"`  /* in microseconds, 0 if unlimited */
+	PeriodUs   uint64 `json:"period_us,omitempty"` /* in microseconds */
+	CpusetCpus uint64 `json:"cpuset_cpus,omitempty"`
+	OnlineCpus uint64 `json:"online_cpus,omitempty"`
 
 	// Derived
-	ThrottledPct     float64
+	ThrottledPct float64 `json:"throttled_pct,omitempty"`
 
-	SampleTime       time.Time
+	SampleTime time.Time `json:"-"`
 }
 
 type CpuDeltaStat struct {
-	UsagePct       float64
-	UserUsagePct   float64
-	SystemUsagePct float64
+	UsagePct       float64 `json:"usage_pct,omitempty"`
+	UserUsagePct   float64 `json:"user_usage_pct,omitempty"`
+	SystemUsagePct float64 `json:"system_usage_pct,omitempty"`
+
+	// Indexed by CPU, only set if cpuacct.usage_percpu/usage_all exist
+	PerCpuUsagePct       []float64 `json:"per_cpu_usage_pct,omitempty"`
+	PerCpuUserUsagePct   []float64 `json:"per_cpu_user_usage_pct,omitempty"`
+	PerCpuSystemUsagePct []float64 `json:"per_cpu_system_usage_pct,omitempty"`
+
+	// UsagePct is relative to a single CPU; these are relative to the
+	// CFS quota (0 if unlimited), the effective cpuset and the host's
+	// online CPUs respectively.
+	CoresUsed      float64 `json:"cores_used,omitempty"`
+	QuotaUsagePct  float64 `json:"quota_usage_pct,omitempty"`
+	CpusetUsagePct float64 `json:"cpuset_usage_pct,omitempty"`
+	OnlineUsagePct float64 `json:"online_usage_pct,omitempty"`
+
+	// Throttling over the sample interval, rather than since the
+	// cgroup was created.
+	ThrottledPct      float64 `json:"throttled_pct,omitempty"`
+	ThrottledUsPerSec float64 `json:"throttled_us_per_sec,omitempty"`
+	BurstRate         float64 `json:"bursts_per_sec,omitempty"`
+	BurstUsPerSec     float64 `json:"burst_us_per_sec,omitempty"`
 }
 
 const (
-	ControllerCpu = "cpu"
+	ControllerCpu     = "cpu"
+	ControllerCpuacct = "cpuacct"
 )
 
 func (stats CpuStat) Delta(prevStats CpuStat) CpuDeltaStat {
@@ -47,11 +83,75 @@ func CalcCpuDeltaStats(stats CpuStat, prevStats CpuStat) CpuDeltaStat {
 
 	deltaStat.UserUsagePct = 100.0 * float64(userTimeDeltaUs) / float64(timeDeltaUs)
 	deltaStat.SystemUsagePct = 100.0 * float64(systemTimeDeltaUs) / float64(timeDeltaUs)
-	deltaStat.UsagePct = 100.0 * float64(userTimeDeltaUs + systemTimeDeltaUs) / float64(timeDeltaUs)
+	deltaStat.UsagePct = 100.0 * float64(userTimeDeltaUs+systemTimeDeltaUs) / float64(timeDeltaUs)
+
+	// Prefer the nanosecond counter over the USER_HZ based one when
+	// both samples have it, splitting it between user and system in the
+	// ratio of the tick based counters so that the three add up.
+	if stats.UsageNs != 0 && prevStats.UsageNs != 0 {
+		usageDeltaNs := stats.UsageNs - prevStats.UsageNs
+		deltaStat.UsagePct = 100.0 * float64(usageDeltaNs) / float64(timeDeltaUs*1000)
+
+		if ticksDeltaUs := userTimeDeltaUs + systemTimeDeltaUs; ticksDeltaUs != 0 {
+			deltaStat.UserUsagePct = deltaStat.UsagePct * float64(userTimeDeltaUs) / float64(ticksDeltaUs)
+			deltaStat.SystemUsagePct = deltaStat.UsagePct * float64(systemTimeDeltaUs) / float64(ticksDeltaUs)
+		}
+	}
+
+	periodsDelta := stats.Periods - prevStats.Periods
+	throttledPeriodsDelta := stats.ThrottledPeriods - prevStats.ThrottledPeriods
+	throttledTimeDeltaUs := stats.ThrottledTimeUs - prevStats.ThrottledTimeUs
+	burstsDelta := stats.Bursts - prevStats.Bursts
+	burstTimeDeltaUs := stats.BurstTimeUs - prevStats.BurstTimeUs
+
+	if periodsDelta != 0 {
+		deltaStat.ThrottledPct = 100.0 * float64(throttledPeriodsDelta) / float64(periodsDelta)
+	}
+
+	deltaStat.ThrottledUsPerSec = float64(throttledTimeDeltaUs*1000*1000) / float64(timeDeltaUs)
+	deltaStat.BurstRate = float64(burstsDelta*1000*1000) / float64(timeDeltaUs)
+	deltaStat.BurstUsPerSec = float64(burstTimeDeltaUs*1000*1000) / float64(timeDeltaUs)
+
+	deltaStat.CoresUsed = deltaStat.UsagePct / 100.0
+
+	if stats.QuotaUs != 0 && stats.PeriodUs != 0 {
+		quotaCores := float64(stats.QuotaUs) / float64(stats.PeriodUs)
+		deltaStat.QuotaUsagePct = deltaStat.UsagePct / quotaCores
+	}
+
+	if stats.CpusetCpus != 0 {
+		deltaStat.CpusetUsagePct = deltaStat.UsagePct / float64(stats.CpusetCpus)
+	}
+
+	if stats.OnlineCpus != 0 {
+		deltaStat.OnlineUsagePct = deltaStat.UsagePct / float64(stats.OnlineCpus)
+	}
+
+	deltaStat.PerCpuUsagePct = perCpuDeltaPct(stats.PerCpuUsageNs, prevStats.PerCpuUsageNs, timeDeltaUs)
+	deltaStat.PerCpuUserUsagePct = perCpuDeltaPct(stats.PerCpuUserNs, prevStats.PerCpuUserNs, timeDeltaUs)
+	deltaStat.PerCpuSystemUsagePct = perCpuDeltaPct(stats.PerCpuSystemNs, prevStats.PerCpuSystemNs, timeDeltaUs)
 
 	return deltaStat
 }
 
+func perCpuDeltaPct(usageNs []uint64, prevUsageNs []uint64, timeDeltaUs int64) []float64 {
+	n := len(usageNs)
+	if len(prevUsageNs) < n {
+		n = len(prevUsageNs)
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	pcts := make([]float64, n)
+	for i := 0; i < n; i++ {
+		pcts[i] = 100.0 * float64(usageNs[i]-prevUsageNs[i]) / float64(timeDeltaUs*1000)
+	}
+
+	return pcts
+}
+
 var ticksPerSec = uint64(sysconfClockTicks())
 
 func populateCpuStat(cg Cgroup, stat *CpuStat) error {
@@ -60,7 +160,7 @@ func populateCpuStat(cg Cgroup, stat *CpuStat) error {
 		return err
 	}
 
-	fd, err := os.Open(path)
+	fd, err := fsOpen(cg, path)
 	if err != nil {
 		return err
 	}
@@ -80,7 +180,22 @@ func populateCpuStat(cg Cgroup, stat *CpuStat) error {
 		case "nr_throttled":
 			stat.ThrottledPeriods = value
 		case "throttled_time":
-			stat.ThrottledTimeUs = ticksToUs(value)
+			// v1 reports this in nanoseconds, unlike cpuacct.stat
+			stat.ThrottledTimeUs = value / 1000
+		case "throttled_usec":
+			stat.ThrottledTimeUs = value
+		case "nr_bursts":
+			stat.Bursts = value
+		case "burst_time":
+			stat.BurstTimeUs = value / 1000
+		case "burst_usec":
+			stat.BurstTimeUs = value
+		case "usage_usec":
+			stat.UsageNs = value * 1000
+		case "user_usec":
+			stat.UserTimeUs = value
+		case "system_usec":
+			stat.SystemTimeUs = value
 		}
 	}
 
@@ -94,12 +209,17 @@ func populateCpuStat(cg Cgroup, stat *CpuStat) error {
 }
 
 func populateCpuacctStat(cg Cgroup, stat *CpuStat) error {
-	path, err := GetCgroupPath(cg, ControllerCpu, "cpuacct.stat")
+	// On v2, user and system time are part of cpu.stat
+	if isUnified(cg, ControllerCpuacct) {
+		return nil
+	}
+
+	path, err := GetCgroupPath(cg, ControllerCpuacct, "cpuacct.stat")
 	if err == ErrNoCgroup {
 		return err
 	}
 
-	fd, err := os.Open(path)
+	fd, err := fsOpen(cg, path)
 	if err != nil {
 		return err
 	}
@@ -138,9 +258,160 @@ func GetCpuStats(cg Cgroup) (CpuStat, error) {
 		return stats, err
 	}
 
+	err = populateCpuacctUsage(cg, &stats)
+	if err != nil {
+		return stats, err
+	}
+
+	err = populateCpuLimits(cg, &stats)
+	if err != nil {
+		return stats, err
+	}
+
 	return stats, nil
 }
 
+// populateCpuLimits fills in the denominators used to normalize usage.
+// Not every hierarchy has a quota or cpuset, so those failures are
+// ignored, but the online CPUs are always listed in sysfs.
+func populateCpuLimits(cg Cgroup, stat *CpuStat) error {
+	stat.QuotaUs, stat.PeriodUs, _ = readCpuQuota(cg)
+
+	if cpus, err := GetCpusetCpus(cg); err == nil {
+		stat.CpusetCpus = uint64(len(cpus))
+	}
+
+	var err error
+	stat.OnlineCpus, err = onlineCpuCount(cg)
+
+	return err
+}
+
+// readCpuQuota returns the CFS quota and period in microseconds, with a
+// quota of 0 meaning unlimited.
+func readCpuQuota(cg Cgroup) (uint64, uint64, error) {
+	path, err := GetCgroupPath(cg, ControllerCpu, "cpu.max")
+	if err == ErrNoCgroup {
+		return 0, 0, err
+	}
+
+	if contentsRaw, err := fsReadFile(cg, path); err == nil {
+		fields := strings.Fields(string(contentsRaw))
+		if len(fields) != 2 {
+			return 0, 0, ErrInvalidFormat
+		}
+
+		period, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if fields[0] == "max" {
+			return 0, period, nil
+		}
+
+		quota, err := strconv.ParseUint(fields[0], 10, 64)
+		return quota, period, err
+	}
+
+	path, _ = GetCgroupPath(cg, ControllerCpu, "cpu.cfs_quota_us")
+	contentsRaw, err := fsReadFile(cg, path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	quota, err := strconv.ParseInt(strings.TrimSpace(string(contentsRaw)), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	path, _ = GetCgroupPath(cg, ControllerCpu, "cpu.cfs_period_us")
+	contentsRaw, err = fsReadFile(cg, path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	period, err := strconv.ParseUint(strings.TrimSpace(string(contentsRaw)), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if quota < 0 {
+		return 0, period, nil
+	}
+
+	return uint64(quota), period, nil
+}
+
+func populateCpuacctUsage(cg Cgroup, stat *CpuStat) error {
+	path, err := GetCgroupPath(cg, ControllerCpuacct, "cpuacct.usage")
+	if err == ErrNoCgroup {
+		return err
+	}
+
+	// All of these are optional; usage_all in particular only exists
+	// on 4.16+ kernels.
+	if values, err := readUintList(cg, path); err == nil && len(values) == 1 {
+		stat.UsageNs = values[0]
+	}
+
+	path, _ = GetCgroupPath(cg, ControllerCpuacct, "cpuacct.usage_percpu")
+	if values, err := readUintList(cg, path); err == nil {
+		stat.PerCpuUsageNs = values
+	}
+
+	path, _ = GetCgroupPath(cg, ControllerCpuacct, "cpuacct.usage_all")
+	fd, err := fsOpen(cg, path)
+	if err != nil {
+		return nil
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 3 {
+			continue
+		}
+
+		user, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		system, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		stat.PerCpuUserNs = append(stat.PerCpuUserNs, user)
+		stat.PerCpuSystemNs = append(stat.PerCpuSystemNs, system)
+	}
+
+	return nil
+}
+
+func readUintList(cg Cgroup, path string) ([]uint64, error) {
+	contentsRaw, err := fsReadFile(cg, path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(contentsRaw))
+	values := make([]uint64, 0, len(fields))
+
+	for i := range fields {
+		value, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
 func ticksToUs(ticks uint64) uint64 {
 	return ticks * 1000 * 1000 / ticksPerSec
 }
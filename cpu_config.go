@@ -0,0 +1,134 @@
+package cgroups
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CpuConfig describes what a cgroup is entitled to, as configured on the
+// cpu controller. Fields are normalized across v1 and v2: Shares and
+// Weight are both always set (one derived from the other), and quota,
+// burst and uclamp values are read from whichever file exists.
+type CpuConfig struct {
+	// From cpu.shares (v1) or cpu.weight and cpu.weight.nice (v2)
+	Shares     uint64
+	Weight     uint64
+	WeightNice int64
+
+	// From cpu.cfs_quota_us, cpu.cfs_period_us and cpu.cfs_burst_us
+	// (or cpu.max and cpu.max.burst on v2)
+	QuotaUs  uint64 /* in microseconds, 0 if unlimited */
+	PeriodUs uint64 /* in microseconds */
+	BurstUs  uint64 /* in microseconds */
+
+	// From cpu.idle
+	Idle bool
+
+	// From cpu.uclamp.min and cpu.uclamp.max
+	UclampMin float64 /* in percent */
+	UclampMax float64 /* in percent */
+
+	// From cpu.rt_runtime_us and cpu.rt_period_us (v1 only)
+	RtRuntimeUs int64  /* in microseconds, -1 if unlimited */
+	RtPeriodUs  uint64 /* in microseconds */
+}
+
+const (
+	CpuSharesDefault = 1024
+	CpuWeightDefault = 100
+)
+
+func GetCpuConfig(cg Cgroup) (CpuConfig, error) {
+	var config CpuConfig
+
+	_, err := GetCgroupPath(cg, ControllerCpu, "")
+	if err != nil {
+		return config, err
+	}
+
+	if value, err := readCgroupFile(cg, ControllerCpu, "cpu.shares"); err == nil {
+		config.Shares, _ = strconv.ParseUint(value, 10, 64)
+		config.Weight = CpuSharesToWeight(config.Shares)
+	}
+
+	if value, err := readCgroupFile(cg, ControllerCpu, "cpu.weight"); err == nil {
+		config.Weight, _ = strconv.ParseUint(value, 10, 64)
+		config.Shares = CpuWeightToShares(config.Weight)
+	}
+
+	if value, err := readCgroupFile(cg, ControllerCpu, "cpu.weight.nice"); err == nil {
+		config.WeightNice, _ = strconv.ParseInt(value, 10, 64)
+	}
+
+	config.QuotaUs, config.PeriodUs, _ = readCpuQuota(cg)
+
+	for _, file := range []string{"cpu.max.burst", "cpu.cfs_burst_us"} {
+		if value, err := readCgroupFile(cg, ControllerCpu, file); err == nil {
+			config.BurstUs, _ = strconv.ParseUint(value, 10, 64)
+			break
+		}
+	}
+
+	if value, err := readCgroupFile(cg, ControllerCpu, "cpu.idle"); err == nil {
+		config.Idle = value == "1"
+	}
+
+	config.UclampMin = 0
+	config.UclampMax = 100.0
+
+	if value, err := readCgroupFile(cg, ControllerCpu, "cpu.uclamp.min"); err == nil {
+		config.UclampMin = parseUclamp(value)
+	}
+
+	if value, err := readCgroupFile(cg, ControllerCpu, "cpu.uclamp.max"); err == nil {
+		config.UclampMax = parseUclamp(value)
+	}
+
+	config.RtRuntimeUs = -1
+
+	if value, err := readCgroupFile(cg, ControllerCpu, "cpu.rt_runtime_us"); err == nil {
+		config.RtRuntimeUs, _ = strconv.ParseInt(value, 10, 64)
+	}
+
+	if value, err := readCgroupFile(cg, ControllerCpu, "cpu.rt_period_us"); err == nil {
+		config.RtPeriodUs, _ = strconv.ParseUint(value, 10, 64)
+	}
+
+	return config, nil
+}
+
+// CpuSharesToWeight converts v1 cpu.shares [2, 262144] to v2 cpu.weight
+// [1, 10000], using the same mapping as systemd and runc.
+func CpuSharesToWeight(shares uint64) uint64 {
+	if shares == 0 {
+		return 0
+	}
+
+	if shares < 2 {
+		shares = 2
+	}
+
+	return 1 + ((shares-2)*9999)/262142
+}
+
+// CpuWeightToShares is the inverse of CpuSharesToWeight.
+func CpuWeightToShares(weight uint64) uint64 {
+	if weight == 0 {
+		return 0
+	}
+
+	return 2 + ((weight-1)*262142)/9999
+}
+
+func parseUclamp(value string) float64 {
+	if value == "max" {
+		return 100.0
+	}
+
+	pct, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0
+	}
+
+	return pct
+}
@@ -0,0 +1,41 @@
+package cgroups
+
+import (
+	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestCpuConfig(t *testing.T) {
+	for name, fsys := range fixtures.All {
+		config, err := GetCpuConfig(fixtureCgroup(fsys))
+
+		if err != nil {
+			t.Fail()
+		}
+
+		if config.Shares < 2 || config.Weight < 1 {
+			t.Errorf("%s: %+v\n", name, config)
+		}
+
+		if config.QuotaUs != 200000 || config.PeriodUs != 100000 {
+			t.Errorf("%s: %+v\n", name, config)
+		}
+
+		t.Logf("%s: %+v\n", name, config)
+	}
+}
+
+func TestCpuSharesWeight(t *testing.T) {
+	if CpuSharesToWeight(CpuSharesDefault) != 39 {
+		t.Fail()
+	}
+
+	if CpuSharesToWeight(2) != 1 || CpuSharesToWeight(262144) != 10000 {
+		t.Fail()
+	}
+
+	if CpuWeightToShares(1) != 2 || CpuWeightToShares(10000) != 262144 {
+		t.Fail()
+	}
+}
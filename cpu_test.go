@@ -2,33 +2,176 @@ package cgroups
 
 import (
 	"testing"
+	"time"
+
+	"github.com/bwalex/go-cgroups/fixtures"
 )
 
 func TestCpuStat(t *testing.T) {
-	stats, err := GetCpuStats(Cgroup{ Cgroup: "/system.slice" })
+	for name, fsys := range fixtures.All {
+		stats, err := GetCpuStats(fixtureCgroup(fsys))
+
+		if err != nil {
+			t.Errorf("%s: %v\n", name, err)
+		}
+
+		if stats.UserTimeUs != 450000000 || stats.SystemTimeUs != 120000000 && stats.SystemTimeUs != 124000000 {
+			t.Errorf("%s: %+v\n", name, stats)
+		}
+
+		if stats.UsageNs != 574000000000 || stats.ThrottledTimeUs != 1500000 || stats.ThrottledPct != 2.5 {
+			t.Errorf("%s: %+v\n", name, stats)
+		}
+
+		if stats.QuotaUs != 200000 || stats.PeriodUs != 100000 || stats.CpusetCpus != 4 || stats.OnlineCpus != 8 {
+			t.Errorf("%s: %+v\n", name, stats)
+		}
+
+		t.Logf("%s: %+v\n", name, stats)
+	}
+
+	stats, _ := GetCpuStats(fixtureCgroup(fixtures.V1))
+
+	if len(stats.PerCpuUsageNs) != 8 || len(stats.PerCpuUserNs) != 8 || stats.PerCpuSystemNs[0] != 20000000000 {
+		t.Fail()
+	}
+}
+
+func TestCpuStatNoOnlineCpus(t *testing.T) {
+	cg := fixtureCgroup(fixtures.V2)
+	cg.Root = DefaultSysfsRoot
+	cg.SysRoot = "/nonexistent"
+
+	stats, err := GetCpuStats(cg)
+
+	if err == nil || stats.OnlineCpus != 0 {
+		t.Errorf("expected error, got %+v\n", stats)
+	}
+
+	// Everything read before is still returned
+	if stats.UsageNs != 574000000000 || stats.QuotaUs != 200000 {
+		t.Errorf("%+v\n", stats)
+	}
+}
+
+func TestCpuDeltaPerCpu(t *testing.T) {
+	now := time.Now()
+
+	prev := CpuStat{
+		UsageNs:       1000,
+		PerCpuUsageNs: []uint64{0, 0},
+		SampleTime:    now,
+	}
+
+	cur := CpuStat{
+		UsageNs:       1000 + uint64(time.Second),
+		PerCpuUsageNs: []uint64{uint64(time.Second), 0},
+		SampleTime:    now.Add(time.Second),
+	}
+
+	delta := cur.Delta(prev)
+
+	if delta.UsagePct != 100.0 {
+		t.Fail()
+	}
+
+	if len(delta.PerCpuUsagePct) != 2 || delta.PerCpuUsagePct[0] != 100.0 || delta.PerCpuUsagePct[1] != 0 {
+		t.Fail()
+	}
+
+	if delta.PerCpuUserUsagePct != nil {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", delta)
+}
+
+func TestCpuDeltaNormalized(t *testing.T) {
+	now := time.Now()
+
+	prev := CpuStat{
+		UsageNs:    1,
+		SampleTime: now,
+	}
 
-	if err != nil {
+	cur := CpuStat{
+		UsageNs:    1 + uint64(time.Second),
+		QuotaUs:    200000,
+		PeriodUs:   100000,
+		CpusetCpus: 4,
+		OnlineCpus: 8,
+		SampleTime: now.Add(time.Second / 2),
+	}
+
+	delta := cur.Delta(prev)
+
+	if delta.CoresUsed != 2.0 {
 		t.Fail()
 	}
 
-	if stats.UserTimeUs < 1 {
+	if delta.QuotaUsagePct != 100.0 || delta.CpusetUsagePct != 50.0 || delta.OnlineUsagePct != 25.0 {
 		t.Fail()
 	}
 
-	if stats.SystemTimeUs < 1 {
+	t.Logf("%+v\n", delta)
+}
+
+func TestCpuDeltaThrottling(t *testing.T) {
+	now := time.Now()
+
+	prev := CpuStat{
+		Periods:          1000,
+		ThrottledPeriods: 900,
+		ThrottledTimeUs:  5000000,
+		SampleTime:       now,
+	}
+
+	cur := CpuStat{
+		Periods:          1010,
+		ThrottledPeriods: 901,
+		ThrottledTimeUs:  5020000,
+		Bursts:           2,
+		SampleTime:       now.Add(time.Second),
+	}
+
+	delta := cur.Delta(prev)
+
+	if delta.ThrottledPct != 10.0 {
 		t.Fail()
 	}
 
-	if stats.ThrottledPct < 0 {
+	if delta.ThrottledUsPerSec != 20000.0 || delta.BurstRate != 2.0 {
 		t.Fail()
 	}
 
-	t.Logf("%+v\n", stats)
+	t.Logf("%+v\n", delta)
+}
+
+func TestCpuDeltaUserSystemSplit(t *testing.T) {
+	now := time.Now()
+
+	prev := CpuStat{
+		UsageNs:    1,
+		SampleTime: now,
+	}
+
+	cur := CpuStat{
+		UserTimeUs:   300000,
+		SystemTimeUs: 100000,
+		UsageNs:      1 + uint64(time.Second/2),
+		SampleTime:   now.Add(time.Second),
+	}
+
+	delta := cur.Delta(prev)
+
+	if delta.UsagePct != 50.0 || delta.UserUsagePct != 37.5 || delta.SystemUsagePct != 12.5 {
+		t.Errorf("%+v\n", delta)
+	}
 }
 
 func BenchmarkCpuStat(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := GetCpuStats(Cgroup{ Cgroup: "/system.slice" })
+		_, err := GetCpuStats(fixtureCgroup(fixtures.V1))
 		if err != nil {
 			b.Fail()
 		}
@@ -0,0 +1,108 @@
+package cgroups
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	ControllerCpuset = "cpuset"
+)
+
+// GetCpusetCpus returns the CPUs the cgroup may effectively run on, read
+// from cpuset.effective_cpus (v1) or cpuset.cpus.effective (v2), falling
+// back to cpuset.cpus.
+func GetCpusetCpus(cg Cgroup) ([]int, error) {
+	return readCpusetList(cg, "cpuset.effective_cpus", "cpuset.cpus.effective", "cpuset.cpus")
+}
+
+// GetCpusetMems returns the memory nodes the cgroup may effectively
+// allocate from.
+func GetCpusetMems(cg Cgroup) ([]int, error) {
+	return readCpusetList(cg, "cpuset.effective_mems", "cpuset.mems.effective", "cpuset.mems")
+}
+
+// GetOnlineCpus returns the CPUs that are online on the host.
+func GetOnlineCpus() ([]int, error) {
+	return Host{}.GetOnlineCpus()
+}
+
+func readOnlineCpus(cg Cgroup) ([]int, error) {
+	contentsRaw, err := fsReadFile(cg, sysPath(cg, "devices/system/cpu/online"))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCpuList(string(contentsRaw))
+}
+
+func readCpusetList(cg Cgroup, files ...string) ([]int, error) {
+	for _, file := range files {
+		path, err := GetCgroupPath(cg, ControllerCpuset, file)
+		if err == ErrNoCgroup {
+			return nil, err
+		}
+
+		contentsRaw, err := fsReadFile(cg, path)
+		if err != nil {
+			continue
+		}
+
+		// An empty cpuset.cpus on v2 means "inherit from the parent",
+		// so keep looking.
+		list, err := parseCpuList(string(contentsRaw))
+		if err != nil || len(list) == 0 {
+			continue
+		}
+
+		return list, nil
+	}
+
+	return nil, ErrNoStat
+}
+
+// parseCpuList parses the kernel's list format, e.g. "0-3,8,10-11".
+func parseCpuList(contents string) ([]int, error) {
+	list := make([]int, 0, 8)
+
+	contents = strings.TrimSpace(contents)
+	if contents == "" {
+		return list, nil
+	}
+
+	for _, r := range strings.Split(contents, ",") {
+		bounds := strings.SplitN(r, "-", 2)
+
+		lo, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+
+		hi := lo
+		if len(bounds) == 2 {
+			hi, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for i := lo; i <= hi; i++ {
+			list = append(list, i)
+		}
+	}
+
+	return list, nil
+}
+
+func onlineCpuCount(cg Cgroup) (uint64, error) {
+	cpus, err := readOnlineCpus(cg)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(cpus) == 0 {
+		return 0, ErrInvalidFormat
+	}
+
+	return uint64(len(cpus)), nil
+}
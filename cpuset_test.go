@@ -0,0 +1,57 @@
+package cgroups
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestParseCpuList(t *testing.T) {
+	list, err := parseCpuList("0-3,8,10-11\n")
+
+	if err != nil {
+		t.Fail()
+	}
+
+	if !reflect.DeepEqual(list, []int{0, 1, 2, 3, 8, 10, 11}) {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", list)
+}
+
+func TestCpusetCpus(t *testing.T) {
+	for name, fsys := range fixtures.All {
+		cpus, err := GetCpusetCpus(fixtureCgroup(fsys))
+
+		if err != nil {
+			t.Fail()
+		}
+
+		if !reflect.DeepEqual(cpus, []int{0, 1, 2, 3}) {
+			t.Errorf("%s: %+v\n", name, cpus)
+		}
+
+		mems, err := GetCpusetMems(fixtureCgroup(fsys))
+
+		if err != nil || !reflect.DeepEqual(mems, []int{0}) {
+			t.Errorf("%s: %+v\n", name, mems)
+		}
+	}
+}
+
+func TestOnlineCpuCount(t *testing.T) {
+	count, err := onlineCpuCount(fixtureCgroup(fixtures.V2))
+
+	if err != nil || count != 8 {
+		t.Errorf("%d %v\n", count, err)
+	}
+
+	cg := fixtureCgroup(fixtures.V2)
+	cg.SysRoot = "/nonexistent"
+
+	if count, err := onlineCpuCount(cg); err == nil || count != 0 {
+		t.Errorf("expected error, got %d\n", count)
+	}
+}
@@ -0,0 +1,74 @@
+// Package fixtures provides fake root filesystems, with the /sys/fs/cgroup,
+// /proc and /sys files read by go-cgroups, laid out and formatted as on
+// machines with a cgroup v1, hybrid or v2 hierarchy. Each has a cgroup
+// Cgroup with the processes Pids, and can be used as Cgroup.FS to test code
+// built on the library without depending on the host:
+//
+//	cg := cgroups.Cgroup{FS: fixtures.V2, Cgroup: fixtures.Cgroup}
+//	stats, err := cgroups.GetMemoryStats(cg)
+package fixtures
+
+import (
+	"embed"
+	"io/fs"
+	"strings"
+)
+
+const (
+	Cgroup = "/system.slice"
+
+	// An empty child cgroup of Cgroup
+	ChildCgroup = "/system.slice/foo.service"
+
+	// A cpu cgroup with wait_sum in cpu.stat, as on v1 kernels with
+	// schedstats enabled (V1 and Hybrid only)
+	SchedCgroup = "/user.slice"
+)
+
+var (
+	// Process IDs in Cgroup. The first process has two threads, Tids
+	// lists all of them.
+	Pids = []int{1234, 1300}
+	Tids = []int{1234, 1240, 1300}
+)
+
+//go:embed v1 hybrid v2
+var trees embed.FS
+
+var (
+	// Separate hierarchies for each controller, no unified hierarchy
+	V1 = sub("v1")
+
+	// v1 controllers, plus an unified hierarchy with PSI under
+	// /sys/fs/cgroup/unified
+	Hybrid = sub("hybrid")
+
+	// A single unified hierarchy
+	V2 = sub("v2")
+)
+
+// All maps the name of each tree to the tree.
+var All = map[string]fs.FS{
+	"v1":     V1,
+	"hybrid": Hybrid,
+	"v2":     V2,
+}
+
+// Module files cannot have a ":" in their name, so the trees store the
+// major:minor directories of /sys/dev/block as e.g. "8%3A0".
+type tree struct {
+	fsys fs.FS
+}
+
+func (t *tree) Open(name string) (fs.File, error) {
+	return t.fsys.Open(strings.Replace(name, ":", "%3A", -1))
+}
+
+func sub(dir string) fs.FS {
+	fsys, err := fs.Sub(trees, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	return &tree{fsys}
+}
@@ -0,0 +1,114 @@
+package cgroups
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// All file access goes through the helpers below, so that a Cgroup with
+// an FS reads a fake /sys/fs/cgroup and /proc instead of the host's. The
+// FS is rooted at "/": /proc/1/stat is opened as "proc/1/stat". Anything
+// that implements fs.FS works, e.g. os.DirFS("testdata/v2") or an
+// fstest.MapFS; writes additionally need WriteFileFS.
+
+// WriteFileFS is implemented by filesystems that allow writing control
+// files, as needed by e.g. SetMemoryConfig and MoveTask.
+type WriteFileFS interface {
+	fs.FS
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+var (
+	ErrReadOnlyFS = errors.New("go-cgroups: Filesystem is read-only")
+)
+
+func fsName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
+
+func fsOpen(cg Cgroup, name string) (fs.File, error) {
+	if cg.FS == nil {
+		return os.Open(name)
+	}
+
+	return cg.FS.Open(fsName(name))
+}
+
+func fsReadFile(cg Cgroup, name string) ([]byte, error) {
+	if cg.FS == nil {
+		return ioutil.ReadFile(name)
+	}
+
+	return fs.ReadFile(cg.FS, fsName(name))
+}
+
+func fsStat(cg Cgroup, name string) (fs.FileInfo, error) {
+	if cg.FS == nil {
+		return os.Stat(name)
+	}
+
+	return fs.Stat(cg.FS, fsName(name))
+}
+
+func fsWriteFile(cg Cgroup, name string, data []byte) error {
+	if cg.FS == nil {
+		return ioutil.WriteFile(name, data, 0644)
+	}
+
+	wfs, ok := cg.FS.(WriteFileFS)
+	if !ok {
+		return ErrReadOnlyFS
+	}
+
+	return wfs.WriteFile(fsName(name), data, 0644)
+}
+
+// fsWalkDirs calls fn for root and every directory below it, in lexical
+// order. Directories that disappear during the walk are skipped.
+func fsWalkDirs(cg Cgroup, root string, fn func(dir string) error) error {
+	if cg.FS == nil {
+		return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && p != root {
+					return nil
+				}
+
+				return err
+			}
+
+			if !info.IsDir() {
+				return nil
+			}
+
+			return fn(p)
+		})
+	}
+
+	walkRoot := fsName(root)
+
+	return fs.WalkDir(cg.FS, walkRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p != walkRoot {
+				return nil
+			}
+
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		return fn(path.Join("/", p))
+	})
+}
@@ -0,0 +1,60 @@
+package cgroups
+
+import (
+	"io/fs"
+)
+
+// Host holds where the cgroup, proc and sys filesystems of a machine are
+// found, so that they don't have to be set on every Cgroup. This is
+// needed to monitor the host from a container, where its /proc and /sys
+// are typically mounted elsewhere:
+//
+//	host := cgroups.Host{ProcRoot: "/host/proc", SysRoot: "/host/sys"}
+//	cg, err := host.GetPidCgroup(1234, cgroups.ControllerCpu)
+//	stats := cgroups.GetAllStats(cg, cgroups.StatsOptions{})
+//
+// The zero Host is the machine the caller runs on.
+type Host struct {
+	Root     string // defaults to: DefaultSysfsRoot, or fs/cgroup below SysRoot
+	ProcRoot string // defaults to: DefaultProcRoot
+	SysRoot  string // defaults to: DefaultSysRoot
+	FS       fs.FS  // defaults to the host's filesystem, see fs.go
+}
+
+// Cgroup returns the cgroup with the given path on the host.
+func (h Host) Cgroup(cgroup string) Cgroup {
+	return Cgroup{
+		Root:     h.Root,
+		Cgroup:   cgroup,
+		ProcRoot: h.ProcRoot,
+		SysRoot:  h.SysRoot,
+		FS:       h.FS,
+	}
+}
+
+func (h Host) GetPidCgroup(pid int, controller string) (Cgroup, error) {
+	return readPidCgroup(h.Cgroup("/"), pid, controller)
+}
+
+func (h Host) FindContainerCgroup(id string, controller string) (Cgroup, error) {
+	return FindContainerCgroup(h.Cgroup("/"), id, controller)
+}
+
+func (h Host) GetProcessStat(pid int) (ProcessStat, error) {
+	return getProcessStat(h.Cgroup("/"), pid)
+}
+
+func (h Host) GetOnlineCpus() ([]int, error) {
+	return readOnlineCpus(h.Cgroup("/"))
+}
+
+func (h Host) GetBlockDeviceFromMajMin(majMin string) string {
+	return blockDeviceName(h.Cgroup("/"), majMin)
+}
+
+// GetCgroupMounts returns the cgroup mounts of the host. With a ProcRoot
+// set they are those of its init process, so the mountpoints are paths
+// on the host rather than in the caller's mount namespace.
+func (h Host) GetCgroupMounts() ([]CgroupMount, error) {
+	return readCgroupMounts(h.Cgroup("/"))
+}
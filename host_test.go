@@ -0,0 +1,81 @@
+package cgroups
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+// hostFS makes a fixture tree appear below /host, as the host's
+// filesystems do in a monitoring container.
+type hostFS struct {
+	fsys fs.FS
+}
+
+func (h hostFS) Open(name string) (fs.File, error) {
+	if !strings.HasPrefix(name, "host/") {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return h.fsys.Open(strings.TrimPrefix(name, "host/"))
+}
+
+func TestHostRoots(t *testing.T) {
+	for name, fsys := range fixtures.All {
+		host := Host{ProcRoot: "/host/proc", SysRoot: "/host/sys", FS: hostFS{fsys}}
+
+		cg, err := host.GetPidCgroup(fixtures.Pids[0], ControllerMemory)
+		if err != nil || cg.Cgroup != fixtures.Cgroup || cg.ProcRoot != host.ProcRoot || cg.SysRoot != host.SysRoot {
+			t.Errorf("%s: %+v %v\n", name, cg, err)
+		}
+
+		stats := GetAllStats(cg, StatsOptions{BlkioDevices: true})
+		if stats.Pids.Current != 3 || stats.Memory.MemUsage != 402653184 || stats.Net.Total().TxBytes != 10485760 {
+			t.Errorf("%s: %+v\n", name, stats)
+		}
+
+		if stats.BlkioDevices.Stats["sda"].ServiceBytesRead != 104857600 {
+			t.Errorf("%s: %+v\n", name, stats.BlkioDevices)
+		}
+
+		if stat, err := host.GetProcessStat(fixtures.Pids[0]); err != nil || stat.Comm == "" {
+			t.Errorf("%s: %+v %v\n", name, stat, err)
+		}
+
+		if cpus, err := host.GetOnlineCpus(); err != nil || len(cpus) != 8 {
+			t.Errorf("%s: %v %v\n", name, cpus, err)
+		}
+
+		if dev := host.GetBlockDeviceFromMajMin("253:0"); dev != "dm-0" {
+			t.Errorf("%s: %s\n", name, dev)
+		}
+
+		// The default roots are not found in this FS
+		if _, err := (Host{FS: host.FS}).GetPidCgroup(fixtures.Pids[0], ControllerMemory); err == nil {
+			t.Errorf("%s: found cgroup outside of the roots\n", name)
+		}
+	}
+}
+
+func TestCgroupRoots(t *testing.T) {
+	cg := Cgroup{Cgroup: "/a", SysRoot: "/host/sys", ProcRoot: "/host/proc"}
+
+	if p, _ := GetCgroupPath(Cgroup{Cgroup: "/", SysRoot: "/host/sys", FS: hostFS{fixtures.V2}}, ControllerCpu, "cpu.stat"); p != "/host/sys/fs/cgroup/cpu.stat" {
+		t.Errorf("%s\n", p)
+	}
+
+	if p := procPath(cg, "1", "stat"); p != "/host/proc/1/stat" {
+		t.Errorf("%s\n", p)
+	}
+
+	if p := sysPath(Cgroup{}, "dev/block"); p != SysDevBlockRoot {
+		t.Errorf("%s\n", p)
+	}
+
+	children, err := ListCgroups(Cgroup{Cgroup: "/", SysRoot: "/host/sys", ProcRoot: "/host/proc", FS: hostFS{fixtures.V2}}, ControllerCpu)
+	if err != nil || len(children) < 2 || children[1].ProcRoot != "/host/proc" || children[1].SysRoot != "/host/sys" {
+		t.Errorf("%+v %v\n", children, err)
+	}
+}
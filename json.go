@@ -0,0 +1,316 @@
+package cgroups
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// JSON representation
+//
+// CgroupStats and CgroupDeltaStats marshal to a versioned JSON document
+// meant for exchanging snapshots with other tools:
+//
+//	{
+//	  "schema_version": 1,
+//	  "cgroup": "/system.slice/foo.service",
+//	  "sample_time_ns": 1500000000000000000,
+//	  "cpu": {"user_time_us": 1230000, "usage_ns": 1234567890, ...},
+//	  "memory": {"rss_bytes": 4096, "pgfault": 12, "limit_bytes": ...},
+//	  "blkio": {...},
+//	  "blkio_devices": {"sda": {...}},
+//	  "net": {"eth0": {"rx_bytes": 100, ...}},
+//	  "pids": {...},
+//	  "psi": {"cpu": {"some": {"avg10_pct": 0.5, "total_us": 100}, ...}},
+//	  "errors": {"blkio": "go-cgroups: Could not find path to cgroup"}
+//	}
+//
+// Keys are snake_case and carry their unit as a suffix (_bytes, _us,
+// _ns, _pct, _per_sec); keys without a unit are counts. Zero values are
+// omitted, so a controller that was read but has nothing to report is an
+// empty object, while controllers that failed (they are listed in
+// "errors") or were skipped are left out entirely. Timestamps are
+// nanoseconds since the Unix epoch, and a delta document additionally has
+// "interval_ns". Errors that are one of the package's Err* values decode
+// to that value.
+//
+// Decoding a document and encoding it again yields the same bytes. New
+// keys may be added without changing schema_version; it is only bumped
+// when an existing key changes meaning, and documents with a newer
+// version are rejected.
+
+const (
+	JSONSchemaVersion = 1
+)
+
+var (
+	ErrUnsupportedSchema = errors.New("go-cgroups: Unsupported JSON schema version")
+)
+
+// Errors that decode to themselves rather than to a new error with the
+// same message, so that they can still be compared against.
+var jsonKnownErrors = map[string]error{
+	ErrNoCgroup.Error():          ErrNoCgroup,
+	ErrNoStat.Error():            ErrNoStat,
+	ErrInvalidFormat.Error():     ErrInvalidFormat,
+	ErrReadOnlyFS.Error():        ErrReadOnlyFS,
+	ErrAmbiguousCgroup.Error():   ErrAmbiguousCgroup,
+	ErrInvalidContainer.Error():  ErrInvalidContainer,
+	ErrReclaimTooLarge.Error():   ErrReclaimTooLarge,
+	ErrUnsupportedSchema.Error(): ErrUnsupportedSchema,
+}
+
+type jsonCgroupStats struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Cgroup        string               `json:"cgroup"`
+	Root          string               `json:"root,omitempty"`
+	SampleTimeNs  int64                `json:"sample_time_ns"`
+	Cpu           *CpuStat             `json:"cpu,omitempty"`
+	Memory        *MemoryStat          `json:"memory,omitempty"`
+	Blkio         *BlkioStat           `json:"blkio,omitempty"`
+	BlkioDevices  map[string]BlkioStat `json:"blkio_devices,omitempty"`
+	Net           *map[string]NetStat  `json:"net,omitempty"`
+	Pids          *PidsStat            `json:"pids,omitempty"`
+	Psi           *PsiStat             `json:"psi,omitempty"`
+	Errors        map[string]string    `json:"errors,omitempty"`
+}
+
+type jsonCgroupDeltaStats struct {
+	SchemaVersion int               `json:"schema_version"`
+	Cgroup        string            `json:"cgroup"`
+	Root          string            `json:"root,omitempty"`
+	SampleTimeNs  int64             `json:"sample_time_ns"`
+	IntervalNs    int64             `json:"interval_ns"`
+	Cpu           *CpuDeltaStat     `json:"cpu,omitempty"`
+	Memory        *MemoryDeltaStat  `json:"memory,omitempty"`
+	Blkio         *BlkioDeltaStat   `json:"blkio,omitempty"`
+	Net           *NetDeltaStat     `json:"net,omitempty"`
+	Errors        map[string]string `json:"errors,omitempty"`
+}
+
+func (stats CgroupStats) MarshalJSON() ([]byte, error) {
+	stats.shareSampleTime()
+
+	doc := jsonCgroupStats{
+		SchemaVersion: JSONSchemaVersion,
+		Cgroup:        stats.Cgroup.Cgroup,
+		Root:          stats.Cgroup.Root,
+		SampleTimeNs:  unixNanos(stats.SampleTime),
+		Errors:        errorStrings(stats.Errors),
+	}
+
+	if stats.Collected(ControllerCpu) {
+		doc.Cpu = &stats.Cpu
+	}
+
+	if stats.Collected(ControllerMemory) {
+		doc.Memory = &stats.Memory
+	}
+
+	if stats.Collected(ControllerBlkio) {
+		doc.Blkio = &stats.Blkio
+		doc.BlkioDevices = stats.BlkioDevices.Stats
+	}
+
+	if stats.Collected(ControllerNet) {
+		netStats := stats.Net.Stats
+		if netStats == nil {
+			netStats = make(map[string]NetStat)
+		}
+		doc.Net = &netStats
+	}
+
+	if stats.Collected(ControllerPids) {
+		doc.Pids = &stats.Pids
+	}
+
+	if stats.Collected(ControllerPsi) {
+		doc.Psi = &stats.Psi
+	}
+
+	return json.Marshal(doc)
+}
+
+func (stats *CgroupStats) UnmarshalJSON(data []byte) error {
+	var doc jsonCgroupStats
+
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+
+	if doc.SchemaVersion < 1 || doc.SchemaVersion > JSONSchemaVersion {
+		return ErrUnsupportedSchema
+	}
+
+	*stats = CgroupStats{
+		Cgroup:     Cgroup{Root: doc.Root, Cgroup: doc.Cgroup},
+		Errors:     stringErrors(doc.Errors),
+		SampleTime: jsonTime(doc.SampleTimeNs),
+		Options: StatsOptions{
+			SkipCpu:      jsonSkipped(doc.Errors, ControllerCpu, doc.Cpu != nil),
+			SkipMemory:   jsonSkipped(doc.Errors, ControllerMemory, doc.Memory != nil),
+			SkipBlkio:    jsonSkipped(doc.Errors, ControllerBlkio, doc.Blkio != nil),
+			SkipNet:      jsonSkipped(doc.Errors, ControllerNet, doc.Net != nil),
+			SkipPids:     jsonSkipped(doc.Errors, ControllerPids, doc.Pids != nil),
+			SkipPsi:      jsonSkipped(doc.Errors, ControllerPsi, doc.Psi != nil),
+			BlkioDevices: doc.BlkioDevices != nil,
+		},
+	}
+
+	if doc.Cpu != nil {
+		stats.Cpu = *doc.Cpu
+	}
+
+	if doc.Memory != nil {
+		stats.Memory = *doc.Memory
+	}
+
+	if doc.Blkio != nil {
+		stats.Blkio = *doc.Blkio
+	}
+
+	if doc.BlkioDevices != nil {
+		stats.BlkioDevices.Stats = doc.BlkioDevices
+	}
+
+	if doc.Net != nil {
+		stats.Net.Stats = *doc.Net
+	}
+
+	if doc.Pids != nil {
+		stats.Pids = *doc.Pids
+	}
+
+	if doc.Psi != nil {
+		stats.Psi = *doc.Psi
+	}
+
+	stats.shareSampleTime()
+
+	return nil
+}
+
+func (delta CgroupDeltaStats) MarshalJSON() ([]byte, error) {
+	doc := jsonCgroupDeltaStats{
+		SchemaVersion: JSONSchemaVersion,
+		Cgroup:        delta.Cgroup.Cgroup,
+		Root:          delta.Cgroup.Root,
+		SampleTimeNs:  unixNanos(delta.SampleTime),
+		IntervalNs:    delta.Interval.Nanoseconds(),
+		Errors:        errorStrings(delta.Errors),
+	}
+
+	if delta.Collected(ControllerCpu) {
+		doc.Cpu = &delta.Cpu
+	}
+
+	if delta.Collected(ControllerMemory) {
+		doc.Memory = &delta.Memory
+	}
+
+	if delta.Collected(ControllerBlkio) {
+		doc.Blkio = &delta.Blkio
+	}
+
+	if delta.Collected(ControllerNet) {
+		doc.Net = &delta.Net
+	}
+
+	return json.Marshal(doc)
+}
+
+func (delta *CgroupDeltaStats) UnmarshalJSON(data []byte) error {
+	var doc jsonCgroupDeltaStats
+
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+
+	if doc.SchemaVersion < 1 || doc.SchemaVersion > JSONSchemaVersion {
+		return ErrUnsupportedSchema
+	}
+
+	*delta = CgroupDeltaStats{
+		Cgroup:     Cgroup{Root: doc.Root, Cgroup: doc.Cgroup},
+		Errors:     stringErrors(doc.Errors),
+		Interval:   time.Duration(doc.IntervalNs),
+		SampleTime: jsonTime(doc.SampleTimeNs),
+		Options: StatsOptions{
+			SkipCpu:    jsonSkipped(doc.Errors, ControllerCpu, doc.Cpu != nil),
+			SkipMemory: jsonSkipped(doc.Errors, ControllerMemory, doc.Memory != nil),
+			SkipBlkio:  jsonSkipped(doc.Errors, ControllerBlkio, doc.Blkio != nil),
+			SkipNet:    jsonSkipped(doc.Errors, ControllerNet, doc.Net != nil),
+			SkipPids:   true,
+			SkipPsi:    true,
+		},
+	}
+
+	if doc.Cpu != nil {
+		delta.Cpu = *doc.Cpu
+	}
+
+	if doc.Memory != nil {
+		delta.Memory = *doc.Memory
+	}
+
+	if doc.Blkio != nil {
+		delta.Blkio = *doc.Blkio
+	}
+
+	if doc.Net != nil {
+		delta.Net = *doc.Net
+	}
+
+	return nil
+}
+
+// jsonSkipped reports whether a controller missing from a document was
+// skipped rather than failed.
+func jsonSkipped(errs map[string]string, controller string, present bool) bool {
+	_, failed := errs[controller]
+	return !present && !failed
+}
+
+func jsonTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ns)
+}
+
+func unixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func errorStrings(errs map[string]error) map[string]string {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	strs := make(map[string]string, len(errs))
+	for controller, err := range errs {
+		strs[controller] = err.Error()
+	}
+
+	return strs
+}
+
+func stringErrors(strs map[string]string) map[string]error {
+	errs := make(map[string]error, len(strs))
+	for controller, str := range strs {
+		if err, ok := jsonKnownErrors[str]; ok {
+			errs[controller] = err
+		} else {
+			errs[controller] = errors.New(str)
+		}
+	}
+
+	return errs
+}
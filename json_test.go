@@ -0,0 +1,148 @@
+package cgroups
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsJSON(t *testing.T) {
+	stats := CgroupStats{
+		Cgroup:     Cgroup{Cgroup: "/system.slice"},
+		SampleTime: time.Unix(1500000000, 123456789),
+		Cpu:        CpuStat{UserTimeUs: 1230000, UsageNs: 1234567890, PerCpuUsageNs: []uint64{1, 2}},
+		Memory:     MemoryStat{RSS: 4096, PgFault: 12},
+		Net:        NetItemizedStats{Stats: map[string]NetStat{"eth0": NetStat{RxBytes: 100}}},
+		Errors:     map[string]error{ControllerBlkio: errors.New("no blkio")},
+		Options:    StatsOptions{SkipPids: true, SkipPsi: true},
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("%s\n", data)
+
+	for _, key := range []string{`"schema_version":1`, `"sample_time_ns":1500000000123456789`, `"user_time_us":1230000`, `"rss_bytes":4096`, `"rx_bytes":100`, `"blkio":"no blkio"`} {
+		if !bytes.Contains(data, []byte(key)) {
+			t.Errorf("missing %s", key)
+		}
+	}
+
+	if bytes.Contains(data, []byte(`"pids"`)) || bytes.Contains(data, []byte(`"psi"`)) {
+		t.Fail()
+	}
+
+	var decoded CgroupStats
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Cpu.UsageNs != stats.Cpu.UsageNs || decoded.Net.Stats["eth0"].RxBytes != 100 ||
+		!decoded.Cpu.SampleTime.Equal(stats.SampleTime) || decoded.Errors[ControllerBlkio] == nil ||
+		!decoded.Options.SkipPids || decoded.Options.SkipBlkio || decoded.Collected(ControllerBlkio) {
+		t.Fail()
+	}
+
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, again) {
+		t.Errorf("round trip mismatch:\n%s\n%s", data, again)
+	}
+}
+
+func TestDeltaJSON(t *testing.T) {
+	delta := CgroupDeltaStats{
+		Cgroup:     Cgroup{Cgroup: "/system.slice"},
+		SampleTime: time.Unix(1500000000, 0),
+		Interval:   2 * time.Second,
+		Cpu:        CpuDeltaStat{UsagePct: 12.5},
+		Memory:     MemoryDeltaStat{PgFaultRate: 7},
+	}
+
+	data, err := json.Marshal(delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), `"interval_ns":2000000000`) || !strings.Contains(string(data), `"pgfaults_per_sec":7`) {
+		t.Errorf("%s", data)
+	}
+
+	var decoded CgroupDeltaStats
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := json.Marshal(decoded)
+	if !bytes.Equal(data, again) || decoded.Interval != delta.Interval {
+		t.Errorf("round trip mismatch:\n%s\n%s", data, again)
+	}
+
+	if err := json.Unmarshal([]byte(`{"schema_version":99}`), &decoded); err != ErrUnsupportedSchema {
+		t.Fail()
+	}
+}
+
+func TestStatsJSONIdle(t *testing.T) {
+	stats := CgroupStats{
+		Cgroup:  Cgroup{Cgroup: "/system.slice"},
+		Options: StatsOptions{SkipBlkio: true, SkipPsi: true},
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{`"cpu":{}`, `"memory":{}`, `"net":{}`, `"pids":{}`} {
+		if !bytes.Contains(data, []byte(key)) {
+			t.Errorf("missing %s in %s", key, data)
+		}
+	}
+
+	if bytes.Contains(data, []byte(`"blkio"`)) || bytes.Contains(data, []byte(`"psi"`)) {
+		t.Errorf("skipped controller in %s", data)
+	}
+
+	var decoded CgroupStats
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !decoded.SampleTime.IsZero() || !decoded.Cpu.SampleTime.IsZero() {
+		t.Errorf("expected zero sample time, got %v", decoded.SampleTime)
+	}
+
+	if !decoded.Collected(ControllerCpu) || !decoded.Collected(ControllerNet) || decoded.Collected(ControllerBlkio) {
+		t.Errorf("unexpected options %+v", decoded.Options)
+	}
+}
+
+func TestStatsJSONErrors(t *testing.T) {
+	stats := CgroupStats{
+		Cgroup: Cgroup{Cgroup: "/system.slice"},
+		Errors: map[string]error{ControllerMemory: ErrNoCgroup, ControllerBlkio: errors.New("no blkio")},
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded CgroupStats
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Errors[ControllerMemory] != ErrNoCgroup || decoded.Errors[ControllerBlkio].Error() != "no blkio" {
+		t.Errorf("%+v\n", decoded.Errors)
+	}
+}
@@ -0,0 +1,127 @@
+package lineproto
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GraphiteEncoder writes Graphite plaintext lines of the form
+// "<prefix>.<measurement>.<tag values>.<field> <value> <unix seconds>".
+// Tag values are escaped so that they form exactly one path component
+// each, except for cgroup paths, which are turned into one component per
+// directory level (e.g. "/system.slice/foo.service" becomes
+// "system%2Eslice.foo%2Eservice"). A zero time is written as the current
+// time.
+type GraphiteEncoder struct {
+	w *bufio.Writer
+
+	Prefix string
+}
+
+func NewGraphiteEncoder(w io.Writer, prefix string) *GraphiteEncoder {
+	return &GraphiteEncoder{w: bufio.NewWriter(w), Prefix: prefix}
+}
+
+func (e *GraphiteEncoder) Encode(measurement string, tags []Tag, v interface{}, t time.Time) error {
+	components := make([]string, 0, len(tags)+2)
+
+	if e.Prefix != "" {
+		components = append(components, e.Prefix)
+	}
+
+	components = append(components, GraphiteComponent(measurement))
+
+	for _, tag := range tags {
+		if tag.Key == TagCgroup {
+			components = append(components, GraphiteCgroupPath(tag.Value))
+		} else {
+			components = append(components, GraphiteComponent(tag.Value))
+		}
+	}
+
+	// Graphite has no notion of a line without a timestamp
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	base := strings.Join(components, ".")
+	ts := strconv.FormatInt(t.Unix(), 10)
+
+	for _, f := range fields(v) {
+		e.w.WriteString(base)
+		e.w.WriteByte('.')
+		e.w.WriteString(f.name)
+		e.w.WriteByte(' ')
+		e.w.WriteString(graphiteValue(f.value))
+		e.w.WriteByte(' ')
+		e.w.WriteString(ts)
+		e.w.WriteByte('\n')
+	}
+
+	return e.w.Flush()
+}
+
+// GraphiteComponent makes s safe to use as a single path component by
+// escaping every byte but letters, digits, '-' and '_' as %XX, so that
+// distinct values never map to the same component. The empty string
+// becomes "%00".
+func GraphiteComponent(s string) string {
+	if s == "" {
+		return "%00"
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' {
+			b.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+// GraphiteCgroupPath turns a cgroup path into dot-separated components;
+// the root cgroup becomes "root".
+func GraphiteCgroupPath(cgroupPath string) string {
+	parts := make([]string, 0, 4)
+
+	for _, part := range strings.Split(cgroupPath, "/") {
+		if part == "" {
+			continue
+		}
+
+		parts = append(parts, GraphiteComponent(part))
+	}
+
+	if len(parts) == 0 {
+		return "root"
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func graphiteValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return "1"
+		}
+		return "0"
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+}
@@ -0,0 +1,97 @@
+package lineproto
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxEncoder writes InfluxDB line protocol with nanosecond timestamps.
+// A zero time is left out, so that the server's time is used.
+// Tags are written sorted by key, as recommended by InfluxDB.
+type InfluxEncoder struct {
+	w *bufio.Writer
+
+	// Tags added to every line
+	Tags []Tag
+}
+
+func NewInfluxEncoder(w io.Writer) *InfluxEncoder {
+	return &InfluxEncoder{w: bufio.NewWriter(w)}
+}
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxKeyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+func (e *InfluxEncoder) Encode(measurement string, tags []Tag, v interface{}, t time.Time) error {
+	list := fields(v)
+	if len(list) == 0 {
+		return nil
+	}
+
+	allTags := make([]Tag, 0, len(e.Tags)+len(tags))
+	allTags = append(allTags, e.Tags...)
+	allTags = append(allTags, tags...)
+	sort.SliceStable(allTags, func(i, j int) bool {
+		return allTags[i].Key < allTags[j].Key
+	})
+
+	e.w.WriteString(influxMeasurementEscaper.Replace(measurement))
+
+	for _, tag := range allTags {
+		// Empty tag values are not allowed by the line protocol.
+		if tag.Value == "" {
+			continue
+		}
+
+		e.w.WriteByte(',')
+		e.w.WriteString(influxKeyEscaper.Replace(tag.Key))
+		e.w.WriteByte('=')
+		e.w.WriteString(influxKeyEscaper.Replace(tag.Value))
+	}
+
+	for i, f := range list {
+		if i == 0 {
+			e.w.WriteByte(' ')
+		} else {
+			e.w.WriteByte(',')
+		}
+
+		e.w.WriteString(influxKeyEscaper.Replace(f.name))
+		e.w.WriteByte('=')
+		e.w.WriteString(influxValue(f.value))
+	}
+
+	if !t.IsZero() {
+		e.w.WriteByte(' ')
+		e.w.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+	}
+	e.w.WriteByte('\n')
+
+	return e.w.Flush()
+}
+
+func influxValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10) + "i"
+	default:
+		// Not every InfluxDB version accepts unsigned integers, so only
+		// fall back to a float for values that don't fit in an int64.
+		if v.Uint() > math.MaxInt64 {
+			return strconv.FormatFloat(float64(v.Uint()), 'g', -1, 64)
+		}
+		return strconv.FormatUint(v.Uint(), 10) + "i"
+	}
+}
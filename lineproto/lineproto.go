@@ -0,0 +1,197 @@
+// Package lineproto encodes cgroup stats and deltas as InfluxDB line
+// protocol or Graphite plaintext.
+//
+// Any of the stat or delta structs can be passed to Encode; every numeric
+// field becomes a field with the same name as in the JSON schema (e.g.
+// UserTimeUs becomes user_time_us), or the Go name in snake_case for
+// fields without a JSON name. Slices and maps are skipped, as is
+// SampleTime, which is used as the timestamp instead.
+package lineproto
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+type Tag struct {
+	Key   string
+	Value string
+}
+
+type Encoder interface {
+	Encode(measurement string, tags []Tag, v interface{}, t time.Time) error
+}
+
+// Measurements names the measurement (Influx) or path component
+// (Graphite) used for each kind of stat.
+type Measurements struct {
+	Cpu         string
+	Memory      string
+	Blkio       string
+	Net         string
+	CpuDelta    string
+	MemoryDelta string
+	BlkioDelta  string
+	NetDelta    string
+}
+
+var DefaultMeasurements = Measurements{
+	Cpu:         "cgroup_cpu",
+	Memory:      "cgroup_memory",
+	Blkio:       "cgroup_blkio",
+	Net:         "cgroup_net",
+	CpuDelta:    "cgroup_cpu_delta",
+	MemoryDelta: "cgroup_memory_delta",
+	BlkioDelta:  "cgroup_blkio_delta",
+	NetDelta:    "cgroup_net_delta",
+}
+
+const (
+	TagCgroup    = "cgroup"
+	TagDevice    = "device"
+	TagInterface = "interface"
+)
+
+// EncodeStats encodes the cpu, memory, blkio and net stats of a snapshot,
+// skipping controllers that were not collected. Blkio and net are encoded
+// once per device and interface if itemized stats are available, with a
+// device or interface tag.
+func EncodeStats(enc Encoder, m Measurements, stats cgroups.CgroupStats) error {
+	tags := []Tag{{TagCgroup, stats.Cgroup.Cgroup}}
+
+	if stats.Collected(cgroups.ControllerCpu) {
+		if err := enc.Encode(m.Cpu, tags, stats.Cpu, stats.SampleTime); err != nil {
+			return err
+		}
+	}
+
+	if stats.Collected(cgroups.ControllerMemory) {
+		if err := enc.Encode(m.Memory, tags, stats.Memory, stats.SampleTime); err != nil {
+			return err
+		}
+	}
+
+	if stats.Collected(cgroups.ControllerBlkio) {
+		if len(stats.BlkioDevices.Stats) == 0 {
+			if err := enc.Encode(m.Blkio, tags, stats.Blkio, stats.SampleTime); err != nil {
+				return err
+			}
+		}
+
+		for dev, stat := range stats.BlkioDevices.Stats {
+			devTags := append(tags[:1:1], Tag{TagDevice, dev})
+			if err := enc.Encode(m.Blkio, devTags, stat, stats.SampleTime); err != nil {
+				return err
+			}
+		}
+	}
+
+	if stats.Collected(cgroups.ControllerNet) {
+		for intf, stat := range stats.Net.Stats {
+			intfTags := append(tags[:1:1], Tag{TagInterface, intf})
+			if err := enc.Encode(m.Net, intfTags, stat, stats.SampleTime); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// EncodeDeltas encodes the cpu, memory, blkio and net deltas, timestamped
+// with the end of the interval.
+func EncodeDeltas(enc Encoder, m Measurements, delta cgroups.CgroupDeltaStats) error {
+	tags := []Tag{{TagCgroup, delta.Cgroup.Cgroup}}
+
+	items := []struct {
+		controller  string
+		measurement string
+		v           interface{}
+	}{
+		{cgroups.ControllerCpu, m.CpuDelta, delta.Cpu},
+		{cgroups.ControllerMemory, m.MemoryDelta, delta.Memory},
+		{cgroups.ControllerBlkio, m.BlkioDelta, delta.Blkio},
+		{cgroups.ControllerNet, m.NetDelta, delta.Net},
+	}
+
+	for _, item := range items {
+		if !delta.Collected(item.controller) {
+			continue
+		}
+
+		if err := enc.Encode(item.measurement, tags, item.v, delta.SampleTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type field struct {
+	name  string
+	value reflect.Value
+}
+
+// fields returns the numeric and boolean fields of a struct, in
+// declaration order.
+func fields(v interface{}) []field {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	list := make([]field, 0, rv.NumField())
+
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name := snakeCase(sf.Name)
+		if tag := strings.Split(sf.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+
+		switch sf.Type.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Float32, reflect.Float64, reflect.Bool:
+			list = append(list, field{name, rv.Field(i)})
+		}
+	}
+
+	return list
+}
+
+// snakeCase converts a Go identifier to snake_case, keeping acronyms
+// together: RSSHuge becomes rss_huge and KMemTCPUsage k_mem_tcp_usage.
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
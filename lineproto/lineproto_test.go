@@ -0,0 +1,166 @@
+package lineproto
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"UserTimeUs":     "user_time_us",
+		"RSSHuge":        "rss_huge",
+		"KMemTCPUsage":   "k_mem_tcp_usage",
+		"PerCpuUsageNs":  "per_cpu_usage_ns",
+		"IoRateRead":     "io_rate_read",
+		"ThrottledPct":   "throttled_pct",
+		"MemSwapFailCnt": "mem_swap_fail_cnt",
+	}
+
+	for in, out := range cases {
+		if snakeCase(in) != out {
+			t.Errorf("%s: got %s, want %s", in, snakeCase(in), out)
+		}
+	}
+}
+
+func TestInfluxEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewInfluxEncoder(&buf)
+	enc.Tags = []Tag{{"host", "web 1"}}
+
+	ts := time.Unix(1500000000, 123)
+	stat := cgroups.NetStat{RxBytes: 100, TxBytes: 200, SampleTime: ts}
+
+	err := enc.Encode("cgroup_net", []Tag{{TagCgroup, "/system.slice/a,b.service"}, {TagInterface, "eth0"}}, stat, stat.SampleTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := buf.String()
+
+	if !strings.HasPrefix(line, `cgroup_net,cgroup=/system.slice/a\,b.service,host=web\ 1,interface=eth0 rx_bytes=100i,`) {
+		t.Error(line)
+	}
+
+	if !strings.HasSuffix(line, " 1500000000000000123\n") {
+		t.Error(line)
+	}
+
+	if strings.Contains(line, "sample_time") {
+		t.Error(line)
+	}
+
+	buf.Reset()
+	if err := enc.Encode("cgroup_net", nil, stat, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a timestamp, the line ends with the last field value
+	if !strings.HasSuffix(buf.String(), "i\n") {
+		t.Error(buf.String())
+	}
+}
+
+func TestGraphiteEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewGraphiteEncoder(&buf, "hosts.web1")
+
+	delta := cgroups.CpuDeltaStat{UsagePct: 12.5}
+
+	err := enc.Encode("cgroup_cpu_delta", []Tag{{TagCgroup, "/system.slice/foo.service"}}, delta, time.Unix(1500000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "hosts.web1.cgroup_cpu_delta.system%2Eslice.foo%2Eservice.usage_pct 12.5 1500000000\n") {
+		t.Error(buf.String())
+	}
+
+	if GraphiteCgroupPath("/") != "root" || GraphiteCgroupPath("/a b/c.d") != "a%20b.c%2Ed" {
+		t.Fail()
+	}
+
+	buf.Reset()
+
+	before := time.Now().Unix()
+	if err := enc.Encode("cgroup_cpu_delta", nil, delta, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := strings.Fields(buf.String())
+	if ts, err := strconv.ParseInt(fields[len(fields)-1], 10, 64); err != nil || ts < before || ts > time.Now().Unix() {
+		t.Error(buf.String())
+	}
+
+	// Distinct values stay distinct
+	seen := make(map[string]string)
+	for _, s := range []string{"a.b", "a_b", "a b", "a%2Eb", ""} {
+		c := GraphiteComponent(s)
+		if prev, ok := seen[c]; ok {
+			t.Errorf("%q and %q both map to %q", prev, s, c)
+		}
+		seen[c] = s
+	}
+}
+
+func TestEncodeStats(t *testing.T) {
+	var buf bytes.Buffer
+
+	stats := cgroups.CgroupStats{
+		Cgroup: cgroups.Cgroup{Cgroup: "/"},
+		Net: cgroups.NetItemizedStats{Stats: map[string]cgroups.NetStat{
+			"eth0": {RxBytes: 1},
+		}},
+		Errors:     map[string]error{cgroups.ControllerMemory: cgroups.ErrNoCgroup},
+		SampleTime: time.Unix(1, 0),
+	}
+
+	err := EncodeStats(NewInfluxEncoder(&buf), DefaultMeasurements, stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+
+	if !strings.Contains(out, "cgroup_cpu,cgroup=/ ") || !strings.Contains(out, "cgroup_net,cgroup=/,interface=eth0 ") {
+		t.Error(out)
+	}
+
+	if strings.Contains(out, "cgroup_memory") {
+		t.Error(out)
+	}
+}
+
+func TestEncodeDeltas(t *testing.T) {
+	var buf bytes.Buffer
+
+	delta := cgroups.CgroupDeltaStats{
+		Cgroup:     cgroups.Cgroup{Cgroup: "/"},
+		Cpu:        cgroups.CpuDeltaStat{UsagePct: 12.5},
+		Errors:     map[string]error{cgroups.ControllerMemory: cgroups.ErrNoCgroup},
+		Options:    cgroups.StatsOptions{SkipBlkio: true},
+		SampleTime: time.Unix(1, 0),
+	}
+
+	err := EncodeDeltas(NewInfluxEncoder(&buf), DefaultMeasurements, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+
+	if !strings.Contains(out, "cgroup_cpu_delta,cgroup=/ ") || !strings.Contains(out, "cgroup_net_delta,cgroup=/ ") {
+		t.Error(out)
+	}
+
+	if strings.Contains(out, "cgroup_memory") || strings.Contains(out, "cgroup_blkio") {
+		t.Error(out)
+	}
+}
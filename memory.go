@@ -2,8 +2,6 @@ package cgroups
 
 import (
 	"bufio"
-	"io/ioutil"
-	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -11,61 +9,283 @@ import (
 )
 
 type MemoryStat struct {
-	// From memory.stat
-	Cache              uint64 `stat:"cache"`
-	RSS                uint64 `stat:"rss"`
-	RSSHuge            uint64 `stat:"rss_huge"`
-	PgFault            uint64 `stat:"pgfault"`
-	PgMajFault         uint64 `stat:"pgmajfault"`
-	Swap               uint64 `stat:"swap"`
-	MappedFile         uint64 `stat:"mapped_file"`
-	Unevictable        uint64 `stat:"unevictable"`
-	InactiveAnon       uint64 `stat:"inactive_anon"`
-	ActiveAnon         uint64 `stat:"active_anon"`
-	InactiveFile       uint64 `stat:"inactive_file"`
-	ActiveFile         uint64 `stat:"active_file"`
-
-	TotalCache         uint64 `stat:"total_cache"`
-	TotalRSS           uint64 `stat:"total_rss"`
-	TotalRSSHuge       uint64 `stat:"total_rss_huge"`
-	TotalPgFault       uint64 `stat:"total_pgfault"`
-	TotalPgMajFault    uint64 `stat:"total_pgmajfault"`
-	TotalSwap          uint64 `stat:"total_swap"`
-	TotalMappedFile    uint64 `stat:"total_mapped_file"`
-	TotalUnevictable   uint64 `stat:"total_unevictable"`
-	TotalInactiveAnon  uint64 `stat:"total_inactive_anon"`
-	TotalActiveAnon    uint64 `stat:"total_active_anon"`
-	TotalInactiveFile  uint64 `stat:"total_inactive_file"`
-	TotalActiveFile    uint64 `stat:"total_active_file"`
-
-	// From other files
-	MemUsage           uint64 `file:"memory.usage_in_bytes"`
-	MemUsageMax        uint64 `file:"memory.max_usage_in_bytes"`
-	MemFailCnt         uint64 `file:"memory.failcnt"`
-	MemLimit           uint64 `file:"memory.limit_in_bytes"`
-	MemSwapUsage       uint64 `file:"memory.memsw.usage_in_bytes"`
-	MemSwapUsageMax    uint64 `file:"memory.memsw.max_usage_in_bytes"`
-	MemSwapFailCnt     uint64 `file:"memory.memsw.failcnt"`
-	MemSwapLimit       uint64 `file:"memory.memsw.limit_in_bytes"`
-	KMemUsage          uint64 `file:"memory.kmem.usage_in_bytes"`
-	KMemUsageMax       uint64 `file:"memory.kmem.max_usage_in_bytes"`
-	KMemFailCnt        uint64 `file:"memory.kmem.failcnt"`
-	KMemLimit          uint64 `file:"memory.kmem.limit_in_bytes"`
-
-	SampleTime         time.Time
+	// From memory.stat; where a tag lists more than one key, the first
+	// one (v1) is preferred over the v2 equivalent
+	Cache                  uint64 `stat:"cache,file" json:"cache_bytes,omitempty"`
+	RSS                    uint64 `stat:"rss,anon" json:"rss_bytes,omitempty"`
+	RSSHuge                uint64 `stat:"rss_huge,anon_thp" json:"rss_huge_bytes,omitempty"`
+	PgFault                uint64 `stat:"pgfault" json:"pgfault,omitempty"`
+	PgMajFault             uint64 `stat:"pgmajfault" json:"pgmajfault,omitempty"`
+	Swap                   uint64 `stat:"swap" json:"swap_bytes,omitempty"`
+	MappedFile             uint64 `stat:"mapped_file,file_mapped" json:"mapped_file_bytes,omitempty"`
+	Shmem                  uint64 `stat:"shmem" json:"shmem_bytes,omitempty"`
+	Dirty                  uint64 `stat:"dirty,file_dirty" json:"dirty_bytes,omitempty"`
+	Writeback              uint64 `stat:"writeback,file_writeback" json:"writeback_bytes,omitempty"`
+	SwapCached             uint64 `stat:"swapcached" json:"swapcached_bytes,omitempty"`
+	Unevictable            uint64 `stat:"unevictable" json:"unevictable_bytes,omitempty"`
+	InactiveAnon           uint64 `stat:"inactive_anon" json:"inactive_anon_bytes,omitempty"`
+	ActiveAnon             uint64 `stat:"active_anon" json:"active_anon_bytes,omitempty"`
+	InactiveFile           uint64 `stat:"inactive_file" json:"inactive_file_bytes,omitempty"`
+	ActiveFile             uint64 `stat:"active_file" json:"active_file_bytes,omitempty"`
+	PgPgIn                 uint64 `stat:"pgpgin" json:"pgpgin,omitempty"`
+	PgPgOut                uint64 `stat:"pgpgout" json:"pgpgout,omitempty"`
+	PSwpIn                 uint64 `stat:"pswpin" json:"pswpin,omitempty"`   /* v2 only */
+	PSwpOut                uint64 `stat:"pswpout" json:"pswpout,omitempty"` /* v2 only */
+	WorkingsetRefault      uint64 `stat:"workingset_refault" json:"workingset_refault,omitempty"`
+	WorkingsetRefaultAnon  uint64 `stat:"workingset_refault_anon" json:"workingset_refault_anon,omitempty"`
+	WorkingsetRefaultFile  uint64 `stat:"workingset_refault_file" json:"workingset_refault_file,omitempty"`
+	WorkingsetActivate     uint64 `stat:"workingset_activate" json:"workingset_activate,omitempty"`
+	WorkingsetActivateAnon uint64 `stat:"workingset_activate_anon" json:"workingset_activate_anon,omitempty"`
+	WorkingsetActivateFile uint64 `stat:"workingset_activate_file" json:"workingset_activate_file,omitempty"`
+
+	// From memory.stat, v2 only
+	Kernel                uint64 `stat:"kernel" json:"kernel_bytes,omitempty"`
+	KernelStack           uint64 `stat:"kernel_stack" json:"kernel_stack_bytes,omitempty"`
+	PageTables            uint64 `stat:"pagetables" json:"pagetables_bytes,omitempty"`
+	SecPageTables         uint64 `stat:"sec_pagetables" json:"sec_pagetables_bytes,omitempty"`
+	PerCpu                uint64 `stat:"percpu" json:"percpu_bytes,omitempty"`
+	Sock                  uint64 `stat:"sock" json:"sock_bytes,omitempty"`
+	Vmalloc               uint64 `stat:"vmalloc" json:"vmalloc_bytes,omitempty"`
+	Zswap                 uint64 `stat:"zswap" json:"zswap_bytes,omitempty"`
+	Zswapped              uint64 `stat:"zswapped" json:"zswapped_bytes,omitempty"`
+	FileTHP               uint64 `stat:"file_thp" json:"file_thp_bytes,omitempty"`
+	ShmemTHP              uint64 `stat:"shmem_thp" json:"shmem_thp_bytes,omitempty"`
+	Slab                  uint64 `stat:"slab" json:"slab_bytes,omitempty"`
+	SlabReclaimable       uint64 `stat:"slab_reclaimable" json:"slab_reclaimable_bytes,omitempty"`
+	SlabUnreclaimable     uint64 `stat:"slab_unreclaimable" json:"slab_unreclaimable_bytes,omitempty"`
+	WorkingsetRestoreAnon uint64 `stat:"workingset_restore_anon" json:"workingset_restore_anon,omitempty"`
+	WorkingsetRestoreFile uint64 `stat:"workingset_restore_file" json:"workingset_restore_file,omitempty"`
+	WorkingsetNodeReclaim uint64 `stat:"workingset_nodereclaim" json:"workingset_nodereclaim,omitempty"`
+	PgScan                uint64 `stat:"pgscan" json:"pgscan,omitempty"`
+	PgSteal               uint64 `stat:"pgsteal" json:"pgsteal,omitempty"`
+	PgScanKswapd          uint64 `stat:"pgscan_kswapd" json:"pgscan_kswapd,omitempty"`
+	PgScanDirect          uint64 `stat:"pgscan_direct" json:"pgscan_direct,omitempty"`
+	PgScanKhugepaged      uint64 `stat:"pgscan_khugepaged" json:"pgscan_khugepaged,omitempty"`
+	PgStealKswapd         uint64 `stat:"pgsteal_kswapd" json:"pgsteal_kswapd,omitempty"`
+	PgStealDirect         uint64 `stat:"pgsteal_direct" json:"pgsteal_direct,omitempty"`
+	PgStealKhugepaged     uint64 `stat:"pgsteal_khugepaged" json:"pgsteal_khugepaged,omitempty"`
+	PgRefill              uint64 `stat:"pgrefill" json:"pgrefill,omitempty"`
+	PgActivate            uint64 `stat:"pgactivate" json:"pgactivate,omitempty"`
+	PgDeactivate          uint64 `stat:"pgdeactivate" json:"pgdeactivate,omitempty"`
+	PgLazyFree            uint64 `stat:"pglazyfree" json:"pglazyfree,omitempty"`
+	PgLazyFreed           uint64 `stat:"pglazyfreed" json:"pglazyfreed,omitempty"`
+	ZswpIn                uint64 `stat:"zswpin" json:"zswpin,omitempty"`
+	ZswpOut               uint64 `stat:"zswpout" json:"zswpout,omitempty"`
+	ZswpWb                uint64 `stat:"zswpwb" json:"zswpwb,omitempty"`
+	ThpFaultAlloc         uint64 `stat:"thp_fault_alloc" json:"thp_fault_alloc,omitempty"`
+	ThpCollapseAlloc      uint64 `stat:"thp_collapse_alloc" json:"thp_collapse_alloc,omitempty"`
+	ThpSwpOut             uint64 `stat:"thp_swpout" json:"thp_swpout,omitempty"`
+	ThpSwpOutFallback     uint64 `stat:"thp_swpout_fallback" json:"thp_swpout_fallback,omitempty"`
+
+	TotalCache                  uint64 `stat:"total_cache" json:"total_cache_bytes,omitempty"`
+	TotalRSS                    uint64 `stat:"total_rss" json:"total_rss_bytes,omitempty"`
+	TotalRSSHuge                uint64 `stat:"total_rss_huge" json:"total_rss_huge_bytes,omitempty"`
+	TotalPgFault                uint64 `stat:"total_pgfault" json:"total_pgfault,omitempty"`
+	TotalPgMajFault             uint64 `stat:"total_pgmajfault" json:"total_pgmajfault,omitempty"`
+	TotalSwap                   uint64 `stat:"total_swap" json:"total_swap_bytes,omitempty"`
+	TotalMappedFile             uint64 `stat:"total_mapped_file" json:"total_mapped_file_bytes,omitempty"`
+	TotalUnevictable            uint64 `stat:"total_unevictable" json:"total_unevictable_bytes,omitempty"`
+	TotalInactiveAnon           uint64 `stat:"total_inactive_anon" json:"total_inactive_anon_bytes,omitempty"`
+	TotalActiveAnon             uint64 `stat:"total_active_anon" json:"total_active_anon_bytes,omitempty"`
+	TotalInactiveFile           uint64 `stat:"total_inactive_file" json:"total_inactive_file_bytes,omitempty"`
+	TotalActiveFile             uint64 `stat:"total_active_file" json:"total_active_file_bytes,omitempty"`
+	TotalPgPgIn                 uint64 `stat:"total_pgpgin" json:"total_pgpgin,omitempty"`
+	TotalPgPgOut                uint64 `stat:"total_pgpgout" json:"total_pgpgout,omitempty"`
+	TotalWorkingsetRefaultAnon  uint64 `stat:"total_workingset_refault_anon" json:"total_workingset_refault_anon,omitempty"`
+	TotalWorkingsetRefaultFile  uint64 `stat:"total_workingset_refault_file" json:"total_workingset_refault_file,omitempty"`
+	TotalWorkingsetActivateAnon uint64 `stat:"total_workingset_activate_anon" json:"total_workingset_activate_anon,omitempty"`
+	TotalWorkingsetActivateFile uint64 `stat:"total_workingset_activate_file" json:"total_workingset_activate_file,omitempty"`
+
+	// From other files; where a tag lists more than one file, the
+	// first one (v1) is preferred over the v2 equivalent
+	MemUsage        uint64 `file:"memory.usage_in_bytes,memory.current" json:"usage_bytes,omitempty"`
+	MemUsageMax     uint64 `file:"memory.max_usage_in_bytes,memory.peak" json:"max_usage_bytes,omitempty"`
+	MemFailCnt      uint64 `file:"memory.failcnt" json:"failcnt,omitempty"`
+	MemLimit        uint64 `file:"memory.limit_in_bytes,memory.max" json:"limit_bytes,omitempty"`
+	MemSwapUsage    uint64 `file:"memory.memsw.usage_in_bytes" json:"memsw_usage_bytes,omitempty"`
+	MemSwapUsageMax uint64 `file:"memory.memsw.max_usage_in_bytes" json:"memsw_max_usage_bytes,omitempty"`
+	MemSwapFailCnt  uint64 `file:"memory.memsw.failcnt" json:"memsw_failcnt,omitempty"`
+	MemSwapLimit    uint64 `file:"memory.memsw.limit_in_bytes" json:"memsw_limit_bytes,omitempty"`
+	KMemUsage       uint64 `file:"memory.kmem.usage_in_bytes" json:"kmem_usage_bytes,omitempty"`
+	KMemUsageMax    uint64 `file:"memory.kmem.max_usage_in_bytes" json:"kmem_max_usage_bytes,omitempty"`
+	KMemFailCnt     uint64 `file:"memory.kmem.failcnt" json:"kmem_failcnt,omitempty"`
+	KMemLimit       uint64 `file:"memory.kmem.limit_in_bytes" json:"kmem_limit_bytes,omitempty"`
+	KMemTCPUsage    uint64 `file:"memory.kmem.tcp.usage_in_bytes" json:"kmem_tcp_usage_bytes,omitempty"`
+	KMemTCPUsageMax uint64 `file:"memory.kmem.tcp.max_usage_in_bytes" json:"kmem_tcp_max_usage_bytes,omitempty"`
+	KMemTCPFailCnt  uint64 `file:"memory.kmem.tcp.failcnt" json:"kmem_tcp_failcnt,omitempty"`
+	KMemTCPLimit    uint64 `file:"memory.kmem.tcp.limit_in_bytes" json:"kmem_tcp_limit_bytes,omitempty"`
+
+	// v2 only; unlike memsw on v1, these do not include memory usage
+	SwapUsage uint64 `file:"memory.swap.current" json:"swap_usage_bytes,omitempty"`
+	SwapLimit uint64 `file:"memory.swap.max" json:"swap_limit_bytes,omitempty"`
+
+	SampleTime time.Time `json:"-"`
+}
+
+// Rates are per second; fault, paging and swap rates are in pages.
+type MemoryDeltaStat struct {
+	PgFaultRate            uint64  `json:"pgfaults_per_sec,omitempty"`
+	PgMajFaultRate         float64 `json:"pgmajfaults_per_sec,omitempty"`
+	PgPgInRate             uint64  `json:"pgpgin_per_sec,omitempty"`
+	PgPgOutRate            uint64  `json:"pgpgout_per_sec,omitempty"`
+	SwapInRate             float64 `json:"pswpin_per_sec,omitempty"`  /* v2 only, always 0 on v1 */
+	SwapOutRate            float64 `json:"pswpout_per_sec,omitempty"` /* v2 only, always 0 on v1 */
+	WorkingsetRefaultRate  float64 `json:"workingset_refaults_per_sec,omitempty"`
+	WorkingsetActivateRate float64 `json:"workingset_activates_per_sec,omitempty"`
+	FailCntIncrease        uint64  `json:"failcnt_increase,omitempty"`
+	SwapFailCntIncrease    uint64  `json:"memsw_failcnt_increase,omitempty"`
+	UsageGrowthRate        float64 `json:"usage_growth_bytes_per_sec,omitempty"` /* in bytes, negative when shrinking */
+}
+
+func (stats MemoryStat) Delta(prevStats MemoryStat) MemoryDeltaStat {
+	return CalcMemoryDeltaStats(stats, prevStats)
+}
+
+// CalcMemoryDeltaStats uses the hierarchical (total_*) counters on v1
+// where they exist; on v2 all memory.stat counters are hierarchical.
+func CalcMemoryDeltaStats(stats MemoryStat, prevStats MemoryStat) MemoryDeltaStat {
+	var deltaStat MemoryDeltaStat
+
+	pgFaultDelta := hierDelta(stats.TotalPgFault, prevStats.TotalPgFault, stats.PgFault, prevStats.PgFault)
+	pgMajFaultDelta := hierDelta(stats.TotalPgMajFault, prevStats.TotalPgMajFault, stats.PgMajFault, prevStats.PgMajFault)
+	pgPgInDelta := hierDelta(stats.TotalPgPgIn, prevStats.TotalPgPgIn, stats.PgPgIn, prevStats.PgPgIn)
+	pgPgOutDelta := hierDelta(stats.TotalPgPgOut, prevStats.TotalPgPgOut, stats.PgPgOut, prevStats.PgPgOut)
+	swapInDelta := stats.PSwpIn - prevStats.PSwpIn
+	swapOutDelta := stats.PSwpOut - prevStats.PSwpOut
+	refaultDelta := stats.WorkingsetRefault - prevStats.WorkingsetRefault +
+		hierDelta(stats.TotalWorkingsetRefaultAnon, prevStats.TotalWorkingsetRefaultAnon, stats.WorkingsetRefaultAnon, prevStats.WorkingsetRefaultAnon) +
+		hierDelta(stats.TotalWorkingsetRefaultFile, prevStats.TotalWorkingsetRefaultFile, stats.WorkingsetRefaultFile, prevStats.WorkingsetRefaultFile)
+	activateDelta := stats.WorkingsetActivate - prevStats.WorkingsetActivate +
+		hierDelta(stats.TotalWorkingsetActivateAnon, prevStats.TotalWorkingsetActivateAnon, stats.WorkingsetActivateAnon, prevStats.WorkingsetActivateAnon) +
+		hierDelta(stats.TotalWorkingsetActivateFile, prevStats.TotalWorkingsetActivateFile, stats.WorkingsetActivateFile, prevStats.WorkingsetActivateFile)
+	usageDelta := int64(stats.MemUsage - prevStats.MemUsage)
+
+	timeDeltaMs := uint64(stats.SampleTime.Sub(prevStats.SampleTime).Nanoseconds() / int64(time.Millisecond))
+
+	// Samples less than a millisecond apart have no meaningful rates
+	if timeDeltaMs == 0 {
+		return deltaStat
+	}
+
+	deltaStat.PgFaultRate = (pgFaultDelta * 1000) / timeDeltaMs
+	deltaStat.PgMajFaultRate = float64(pgMajFaultDelta*1000) / float64(timeDeltaMs)
+	deltaStat.PgPgInRate = (pgPgInDelta * 1000) / timeDeltaMs
+	deltaStat.PgPgOutRate = (pgPgOutDelta * 1000) / timeDeltaMs
+	deltaStat.SwapInRate = float64(swapInDelta*1000) / float64(timeDeltaMs)
+	deltaStat.SwapOutRate = float64(swapOutDelta*1000) / float64(timeDeltaMs)
+	deltaStat.WorkingsetRefaultRate = float64(refaultDelta*1000) / float64(timeDeltaMs)
+	deltaStat.WorkingsetActivateRate = float64(activateDelta*1000) / float64(timeDeltaMs)
+	deltaStat.UsageGrowthRate = float64(usageDelta*1000) / float64(timeDeltaMs)
+
+	deltaStat.FailCntIncrease = stats.MemFailCnt - prevStats.MemFailCnt
+	deltaStat.SwapFailCntIncrease = stats.MemSwapFailCnt - prevStats.MemSwapFailCnt
+
+	return deltaStat
+}
+
+func hierDelta(total uint64, prevTotal uint64, local uint64, prevLocal uint64) uint64 {
+	if total != 0 || prevTotal != 0 {
+		return total - prevTotal
+	}
+
+	return local - prevLocal
 }
 
 const (
 	ControllerMemory = "memory"
+
+	// The value of an unlimited memory.limit_in_bytes on v1 (the largest
+	// page-aligned int64). A limit of "max" on v2 is mapped to this as
+	// well.
+	MemoryUnlimited uint64 = 9223372036854771712
+
+	// The v1 unlimited value depends on the page size, e.g. on 64K-page
+	// kernels it is 9223372036854710272. Anything from here up is treated
+	// as unlimited.
+	memoryUnlimitedMin uint64 = 1 << 62
 )
 
+// WorkingSet returns the memory usage minus inactive file-backed pages,
+// which approximates what the OOM killer considers when enforcing the
+// limit.
+func (stats MemoryStat) WorkingSet() uint64 {
+	inactiveFile := stats.TotalInactiveFile
+	if inactiveFile == 0 {
+		inactiveFile = stats.InactiveFile
+	}
+
+	if stats.MemUsage < inactiveFile {
+		return 0
+	}
+
+	return stats.MemUsage - inactiveFile
+}
+
+func (stats MemoryStat) HasLimit() bool {
+	return isMemoryLimited(stats.MemLimit)
+}
+
+// UsagePct returns the memory usage as a percentage of the limit, or 0
+// if the cgroup has no limit.
+func (stats MemoryStat) UsagePct() float64 {
+	if !stats.HasLimit() {
+		return 0
+	}
+
+	return 100.0 * float64(stats.MemUsage) / float64(stats.MemLimit)
+}
+
+// Headroom returns how many more bytes the cgroup can use before hitting
+// its limit. The second return value is false if there is no limit.
+func (stats MemoryStat) Headroom() (uint64, bool) {
+	if !stats.HasLimit() {
+		return 0, false
+	}
+
+	return counterDelta(stats.MemLimit, stats.MemUsage), true
+}
+
+// SwapHeadroom returns how many more bytes of swap the cgroup can use.
+// On v1 this is the memory+swap headroom, as memsw limits both together.
+// The second return value is false if there is no swap limit.
+func (stats MemoryStat) SwapHeadroom() (uint64, bool) {
+	if isMemoryLimited(stats.SwapLimit) {
+		return counterDelta(stats.SwapLimit, stats.SwapUsage), true
+	}
+
+	if isMemoryLimited(stats.MemSwapLimit) {
+		return counterDelta(stats.MemSwapLimit, stats.MemSwapUsage), true
+	}
+
+	return 0, false
+}
+
+func isMemoryLimited(limit uint64) bool {
+	return limit != 0 && limit < memoryUnlimitedMin
+}
+
+// parseMemoryValue parses a memory value, mapping "max" and the v1
+// unlimited value of any page size to MemoryUnlimited.
+func parseMemoryValue(contents string) (uint64, error) {
+	if contents == "max" {
+		return MemoryUnlimited, nil
+	}
+
+	value, err := strconv.ParseUint(contents, 10, 64)
+	if err == nil && value >= memoryUnlimitedMin {
+		value = MemoryUnlimited
+	}
+
+	return value, err
+}
+
 func populateMemoryStat(cg Cgroup, stat *MemoryStat) error {
 	path, err := GetCgroupPath(cg, ControllerMemory, "memory.stat")
 	if err == ErrNoCgroup {
 		return err
 	}
 
-	fd, err := os.Open(path)
+	fd, err := fsOpen(cg, path)
 	if err != nil {
 		return err
 	}
@@ -89,13 +309,16 @@ func populateMemoryStat(cg Cgroup, stat *MemoryStat) error {
 	for i := 0; i < v.NumField(); i++ {
 		tag := v.Type().Field(i).Tag
 
-		stat_name := tag.Get("stat")
-		if stat_name == "" {
+		stat_names := tag.Get("stat")
+		if stat_names == "" {
 			continue
 		}
 
-		if value, found := rawStats[stat_name]; found {
-			v.Field(i).SetUint(value)
+		for _, stat_name := range strings.Split(stat_names, ",") {
+			if value, found := rawStats[stat_name]; found {
+				v.Field(i).SetUint(value)
+				break
+			}
 		}
 	}
 
@@ -107,28 +330,31 @@ func populateMemoryOther(cg Cgroup, stat *MemoryStat) error {
 	for i := 0; i < v.NumField(); i++ {
 		tag := v.Type().Field(i).Tag
 
-		fileName := tag.Get("file")
-		if fileName == "" {
+		fileNames := tag.Get("file")
+		if fileNames == "" {
 			continue
 		}
 
-		path, err := GetCgroupPath(cg, ControllerMemory, fileName)
-		if err == ErrNoCgroup {
-			return err
-		}
+		for _, fileName := range strings.Split(fileNames, ",") {
+			path, err := GetCgroupPath(cg, ControllerMemory, fileName)
+			if err == ErrNoCgroup {
+				return err
+			}
 
-		contentsRaw, err := ioutil.ReadFile(path)
-		if err != nil {
-			continue
-		}
+			contentsRaw, err := fsReadFile(cg, path)
+			if err != nil {
+				continue
+			}
 
-		contents := strings.TrimSpace(string(contentsRaw))
-		value, err := strconv.ParseUint(contents, 10, 64)
-		if err != nil {
-			continue
-		}
+			contents := strings.TrimSpace(string(contentsRaw))
+			value, err := parseMemoryValue(contents)
+			if err != nil {
+				continue
+			}
 
-		v.Field(i).SetUint(value)
+			v.Field(i).SetUint(value)
+			break
+		}
 	}
 
 	return nil
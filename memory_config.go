@@ -0,0 +1,183 @@
+package cgroups
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MemoryConfig holds the configurable memory controller settings. Limits
+// are in bytes, with MemoryUnlimited meaning no limit. Fields tagged with
+// more than one file are read from (and written to) whichever exists,
+// v1 first. Fields tagged with a key are read from a "key value" file.
+//
+// SetMemoryConfig only writes the fields that differ from the current
+// configuration, so the intended use is to modify the result of
+// GetMemoryConfig and pass it back.
+type MemoryConfig struct {
+	Limit                 uint64 `file:"memory.limit_in_bytes,memory.max"`
+	SoftLimit             uint64 `file:"memory.soft_limit_in_bytes"`
+	MemSwapLimit          uint64 `file:"memory.memsw.limit_in_bytes"`
+	Swappiness            uint64 `file:"memory.swappiness"`
+	MoveChargeAtImmigrate uint64 `file:"memory.move_charge_at_immigrate"`
+	UseHierarchy          bool   `file:"memory.use_hierarchy"`
+	OomKillDisable        bool   `file:"memory.oom_control" key:"oom_kill_disable"`
+	UnderOom              bool   `file:"memory.oom_control" key:"under_oom" ro:"true"`
+	OomKill               uint64 `file:"memory.oom_control" key:"oom_kill" ro:"true"`
+
+	// v2 only
+	Low      uint64 `file:"memory.low"`
+	Min      uint64 `file:"memory.min"`
+	High     uint64 `file:"memory.high"`
+	SwapMax  uint64 `file:"memory.swap.max"`
+	ZswapMax uint64 `file:"memory.zswap.max"`
+	OomGroup bool   `file:"memory.oom.group"`
+}
+
+func GetMemoryConfig(cg Cgroup) (MemoryConfig, error) {
+	var config MemoryConfig
+
+	_, err := GetCgroupPath(cg, ControllerMemory, "")
+	if err != nil {
+		return config, err
+	}
+
+	v := reflect.ValueOf(&config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag
+
+		contents, _, err := readMemoryConfigFile(cg, tag.Get("file"))
+		if err != nil {
+			continue
+		}
+
+		if key := tag.Get("key"); key != "" {
+			contents = keyedValue(contents, key)
+		}
+
+		value, err := parseMemoryValue(contents)
+		if err != nil {
+			continue
+		}
+
+		switch v.Field(i).Kind() {
+		case reflect.Bool:
+			v.Field(i).SetBool(value != 0)
+		default:
+			v.Field(i).SetUint(value)
+		}
+	}
+
+	return config, nil
+}
+
+func SetMemoryConfig(cg Cgroup, config MemoryConfig) error {
+	current, err := GetMemoryConfig(cg)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(config)
+	cv := reflect.ValueOf(current)
+	for _, i := range memoryConfigOrder(config, current) {
+		tag := v.Type().Field(i).Tag
+
+		if tag.Get("ro") != "" || v.Field(i).Interface() == cv.Field(i).Interface() {
+			continue
+		}
+
+		_, path, err := readMemoryConfigFile(cg, tag.Get("file"))
+		if err != nil {
+			return err
+		}
+
+		var contents string
+
+		switch v.Field(i).Kind() {
+		case reflect.Bool:
+			contents = "0"
+			if v.Field(i).Bool() {
+				contents = "1"
+			}
+		default:
+			contents = formatMemoryValue(path, v.Field(i).Uint())
+		}
+
+		err = fsWriteFile(cg, path, []byte(contents))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// memoryConfigOrder returns the order in which the fields of config are
+// written. On v1 the kernel rejects a memory.limit_in_bytes above
+// memory.memsw.limit_in_bytes, so when the limit is raised memsw goes
+// first, and when it is lowered last.
+func memoryConfigOrder(config MemoryConfig, current MemoryConfig) []int {
+	t := reflect.TypeOf(config)
+
+	order := make([]int, t.NumField())
+	for i := range order {
+		order[i] = i
+	}
+
+	limit, _ := t.FieldByName("Limit")
+	memsw, _ := t.FieldByName("MemSwapLimit")
+
+	if config.Limit > current.Limit {
+		order[limit.Index[0]], order[memsw.Index[0]] = order[memsw.Index[0]], order[limit.Index[0]]
+	}
+
+	return order
+}
+
+// readMemoryConfigFile returns the contents and path of the first of the
+// comma-separated files that exists.
+func readMemoryConfigFile(cg Cgroup, fileNames string) (string, string, error) {
+	for _, fileName := range strings.Split(fileNames, ",") {
+		path, err := GetCgroupPath(cg, ControllerMemory, fileName)
+		if err != nil {
+			return "", "", err
+		}
+
+		contentsRaw, err := fsReadFile(cg, path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", "", err
+		}
+
+		return strings.TrimSpace(string(contentsRaw)), path, nil
+	}
+
+	return "", "", ErrNoStat
+}
+
+func keyedValue(contents string, key string) string {
+	for _, line := range strings.Split(contents, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) == 2 && parts[0] == key {
+			return parts[1]
+		}
+	}
+
+	return ""
+}
+
+// formatMemoryValue formats a limit for writing, as "-1" on v1 and "max"
+// on v2 when unlimited.
+func formatMemoryValue(path string, value uint64) string {
+	if value < memoryUnlimitedMin {
+		return strconv.FormatUint(value, 10)
+	}
+
+	if strings.HasSuffix(path, "_in_bytes") {
+		return "-1"
+	}
+
+	return "max"
+}
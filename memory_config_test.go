@@ -0,0 +1,198 @@
+package cgroups
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestMemoryConfigV1(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"memory.limit_in_bytes":      "9223372036854771712\n",
+		"memory.soft_limit_in_bytes": "1048576\n",
+		"memory.swappiness":          "60\n",
+		"memory.use_hierarchy":       "1\n",
+		"memory.oom_control":         "oom_kill_disable 0\nunder_oom 1\noom_kill 7\n",
+	}
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(path.Join(root, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cg := Cgroup{Root: root, Cgroup: "/"}
+
+	config, err := GetMemoryConfig(cg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Limit != MemoryUnlimited || config.SoftLimit != 1048576 || config.Swappiness != 60 {
+		t.Fail()
+	}
+
+	if !config.UseHierarchy || config.OomKillDisable || !config.UnderOom || config.OomKill != 7 {
+		t.Fail()
+	}
+
+	config.Limit = 4096
+	config.SoftLimit = MemoryUnlimited
+
+	if err := SetMemoryConfig(cg, config); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, _ := ioutil.ReadFile(path.Join(root, "memory.limit_in_bytes"))
+	if string(contents) != "4096" {
+		t.Fail()
+	}
+
+	contents, _ = ioutil.ReadFile(path.Join(root, "memory.soft_limit_in_bytes"))
+	if string(contents) != "-1" {
+		t.Fail()
+	}
+
+	// Fields that don't exist on this hierarchy must not be touched
+	// unless they are changed.
+	if _, err := os.Stat(path.Join(root, "memory.high")); err == nil {
+		t.Fail()
+	}
+
+	config.High = 1024
+	if err := SetMemoryConfig(cg, config); err == nil {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", config)
+}
+
+// memswFS rejects writes that would leave memory.limit_in_bytes above
+// memory.memsw.limit_in_bytes, like the v1 kernel does.
+type memswFS struct {
+	fstest.MapFS
+}
+
+const memswDir = "sys/fs/cgroup/memory/test/"
+
+func (m memswFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	limits := map[string]uint64{}
+	for _, file := range []string{"memory.limit_in_bytes", "memory.memsw.limit_in_bytes"} {
+		contents := string(m.MapFS[memswDir+file].Data)
+		if memswDir+file == name {
+			contents = string(data)
+		}
+
+		limit, err := strconv.ParseUint(contents, 10, 64)
+		if err != nil {
+			return err
+		}
+		limits[file] = limit
+	}
+
+	if limits["memory.limit_in_bytes"] > limits["memory.memsw.limit_in_bytes"] {
+		return syscall.EINVAL
+	}
+
+	m.MapFS[name] = &fstest.MapFile{Data: data}
+	return nil
+}
+
+func TestMemoryConfigMemsw(t *testing.T) {
+	fsys := memswFS{fstest.MapFS{
+		memswDir + "memory.limit_in_bytes":       {Data: []byte("1048576")},
+		memswDir + "memory.memsw.limit_in_bytes": {Data: []byte("2097152")},
+	}}
+	cg := Cgroup{FS: fsys, Cgroup: "/test"}
+
+	for _, limits := range [][2]uint64{{4194304, 8388608}, {524288, 1048576}} {
+		config, err := GetMemoryConfig(cg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config.Limit = limits[0]
+		config.MemSwapLimit = limits[1]
+
+		if err := SetMemoryConfig(cg, config); err != nil {
+			t.Fatalf("%v: %v\n", limits, err)
+		}
+
+		config, _ = GetMemoryConfig(cg)
+		if config.Limit != limits[0] || config.MemSwapLimit != limits[1] {
+			t.Errorf("%+v\n", config)
+		}
+	}
+}
+
+func TestMemoryConfigV2(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{"memory.max", "memory.high", "memory.low", "memory.swap.max"} {
+		if err := ioutil.WriteFile(path.Join(root, name), []byte("max\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cg := Cgroup{Root: root, Cgroup: "/"}
+
+	config, err := GetMemoryConfig(cg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Limit != MemoryUnlimited || config.High != MemoryUnlimited || config.SwapMax != MemoryUnlimited {
+		t.Fail()
+	}
+
+	config.Low = 8192
+	config.High = MemoryUnlimited
+
+	if err := SetMemoryConfig(cg, config); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, _ := ioutil.ReadFile(path.Join(root, "memory.low"))
+	if string(contents) != "8192" {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", config)
+}
+
+func TestMemoryConfigFixtures(t *testing.T) {
+	config, err := GetMemoryConfig(fixtureCgroup(fixtures.V1))
+
+	if err != nil || config.Limit != 1073741824 || config.Swappiness != 60 || !config.UseHierarchy || config.OomKill != 1 {
+		t.Errorf("%+v\n", config)
+	}
+
+	config, err = GetMemoryConfig(fixtureCgroup(fixtures.V2))
+
+	if err != nil || config.Limit != 1073741824 || config.Low != 0 {
+		t.Errorf("%+v\n", config)
+	}
+
+	config.Limit = 2147483648
+
+	if err := SetMemoryConfig(fixtureCgroup(fixtures.V2), config); err != ErrReadOnlyFS {
+		t.Fail()
+	}
+}
@@ -0,0 +1,147 @@
+package cgroups
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type MemoryEvents struct {
+	// From memory.events or memory.events.local (v2)
+	Low          uint64 `event:"low"`
+	High         uint64 `event:"high"`
+	Max          uint64 `event:"max"`
+	Oom          uint64 `event:"oom"`
+	OomKill      uint64 `event:"oom_kill"`
+	OomGroupKill uint64 `event:"oom_group_kill"`
+
+	// From memory.swap.events (v2, hierarchical only)
+	SwapHigh uint64 `swap:"high"`
+	SwapMax  uint64 `swap:"max"`
+	SwapFail uint64 `swap:"fail"`
+
+	// Set if memory.events does not exist and only OomKill could be
+	// read, from memory.oom_control (v1)
+	FromOomControl bool
+
+	SampleTime time.Time
+}
+
+// Increments of each counter between two samples.
+type MemoryEventsDeltaStat struct {
+	Low          uint64
+	High         uint64
+	Max          uint64
+	Oom          uint64
+	OomKill      uint64
+	OomGroupKill uint64
+	SwapHigh     uint64
+	SwapMax      uint64
+	SwapFail     uint64
+}
+
+func (stats MemoryEvents) Delta(prevStats MemoryEvents) MemoryEventsDeltaStat {
+	return CalcMemoryEventsDeltaStats(stats, prevStats)
+}
+
+func CalcMemoryEventsDeltaStats(stats MemoryEvents, prevStats MemoryEvents) MemoryEventsDeltaStat {
+	var deltaStat MemoryEventsDeltaStat
+
+	deltaStat.Low = stats.Low - prevStats.Low
+	deltaStat.High = stats.High - prevStats.High
+	deltaStat.Max = stats.Max - prevStats.Max
+	deltaStat.Oom = stats.Oom - prevStats.Oom
+	deltaStat.OomKill = stats.OomKill - prevStats.OomKill
+	deltaStat.OomGroupKill = stats.OomGroupKill - prevStats.OomGroupKill
+	deltaStat.SwapHigh = stats.SwapHigh - prevStats.SwapHigh
+	deltaStat.SwapMax = stats.SwapMax - prevStats.SwapMax
+	deltaStat.SwapFail = stats.SwapFail - prevStats.SwapFail
+
+	return deltaStat
+}
+
+// GetMemoryEvents returns the hierarchical memory events, i.e. including
+// those of descendant cgroups.
+func GetMemoryEvents(cg Cgroup) (MemoryEvents, error) {
+	return getMemoryEvents(cg, "memory.events", true)
+}
+
+// GetMemoryEventsLocal returns the memory events of the cgroup itself.
+func GetMemoryEventsLocal(cg Cgroup) (MemoryEvents, error) {
+	return getMemoryEvents(cg, "memory.events.local", false)
+}
+
+func getMemoryEvents(cg Cgroup, fileName string, withSwap bool) (MemoryEvents, error) {
+	var stats MemoryEvents
+
+	stats.SampleTime = time.Now()
+
+	contents, _, err := readMemoryConfigFile(cg, fileName)
+	if err == ErrNoStat {
+		return stats, populateMemoryEventsOomControl(cg, &stats)
+	} else if err != nil {
+		return stats, err
+	}
+
+	populateMemoryEvents(contents, "event", &stats)
+
+	if withSwap {
+		contents, _, err = readMemoryConfigFile(cg, "memory.swap.events")
+		if err == nil {
+			populateMemoryEvents(contents, "swap", &stats)
+		}
+	}
+
+	return stats, nil
+}
+
+func populateMemoryEventsOomControl(cg Cgroup, stat *MemoryEvents) error {
+	contents, _, err := readMemoryConfigFile(cg, "memory.oom_control")
+	if err != nil {
+		return err
+	}
+
+	value, err := strconv.ParseUint(keyedValue(contents, "oom_kill"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	stat.OomKill = value
+	stat.FromOomControl = true
+
+	return nil
+}
+
+func populateMemoryEvents(contents string, tagName string, stat *MemoryEvents) {
+	rawStats := make(map[string]uint64)
+
+	for _, line := range strings.Split(contents, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		rawStats[parts[0]] = value
+	}
+
+	v := reflect.ValueOf(stat).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag
+
+		statName := tag.Get(tagName)
+		if statName == "" {
+			continue
+		}
+
+		if value, found := rawStats[statName]; found {
+			v.Field(i).SetUint(value)
+		}
+	}
+}
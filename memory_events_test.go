@@ -0,0 +1,100 @@
+package cgroups
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestMemoryEventsV2(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"memory.events":       "low 0\nhigh 12\nmax 3\noom 1\noom_kill 1\noom_group_kill 0\n",
+		"memory.events.local": "low 0\nhigh 2\nmax 0\noom 0\noom_kill 0\noom_group_kill 0\n",
+		"memory.swap.events":  "high 0\nmax 5\nfail 1\n",
+	}
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(path.Join(root, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cg := Cgroup{Root: root, Cgroup: "/"}
+
+	events, err := GetMemoryEvents(cg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if events.High != 12 || events.Max != 3 || events.OomKill != 1 || events.SwapMax != 5 || events.SwapFail != 1 {
+		t.Fail()
+	}
+
+	local, err := GetMemoryEventsLocal(cg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if local.High != 2 || local.SwapMax != 0 {
+		t.Fail()
+	}
+
+	delta := events.Delta(local)
+
+	if delta.High != 10 || delta.Max != 3 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", events)
+}
+
+func TestMemoryEventsV1(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	contents := "oom_kill_disable 0\nunder_oom 0\noom_kill 4\n"
+	if err := ioutil.WriteFile(path.Join(root, "memory.oom_control"), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := GetMemoryEvents(Cgroup{Root: root, Cgroup: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !events.FromOomControl || events.OomKill != 4 {
+		t.Fail()
+	}
+}
+
+func TestMemoryEventsFixtures(t *testing.T) {
+	events, err := GetMemoryEvents(fixtureCgroup(fixtures.V2))
+
+	if err != nil || events.Max != 12 || events.OomKill != 1 || events.FromOomControl {
+		t.Errorf("%+v\n", events)
+	}
+
+	events, err = GetMemoryEventsLocal(fixtureCgroup(fixtures.V2))
+
+	if err != nil || events.Max != 2 {
+		t.Errorf("%+v\n", events)
+	}
+
+	events, err = GetMemoryEvents(fixtureCgroup(fixtures.V1))
+
+	if err != nil || events.OomKill != 1 || !events.FromOomControl {
+		t.Errorf("%+v\n", events)
+	}
+}
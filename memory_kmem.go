@@ -0,0 +1,143 @@
+package cgroups
+
+import (
+	"bufio"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SlabCacheStat struct {
+	Name       string
+	ActiveObjs uint64
+	NumObjs    uint64
+	ObjSize    uint64 /* in bytes */
+	Bytes      uint64 /* NumObjs * ObjSize */
+
+	// Share of the host-wide cache (from /proc/slabinfo), if readable
+	HostBytes uint64
+	HostPct   float64
+}
+
+// MemorySlabStat breaks down a cgroup's kernel slab memory. Per-cache
+// data comes from memory.kmem.slabinfo and is only available on v1
+// (and is empty on kernels >= 5.9); on v2 only the reclaimable and
+// unreclaimable totals from memory.stat are available.
+type MemorySlabStat struct {
+	Caches        map[string]SlabCacheStat
+	Reclaimable   uint64 /* in bytes */
+	Unreclaimable uint64 /* in bytes */
+
+	SampleTime time.Time
+}
+
+// TopCaches returns up to n caches, largest first.
+func (stats MemorySlabStat) TopCaches(n int) []SlabCacheStat {
+	caches := make([]SlabCacheStat, 0, len(stats.Caches))
+	for _, cache := range stats.Caches {
+		caches = append(caches, cache)
+	}
+
+	sort.Slice(caches, func(i, j int) bool {
+		if caches[i].Bytes == caches[j].Bytes {
+			return caches[i].Name < caches[j].Name
+		}
+		return caches[i].Bytes > caches[j].Bytes
+	})
+
+	if n >= 0 && len(caches) > n {
+		caches = caches[:n]
+	}
+
+	return caches
+}
+
+func GetMemorySlabStats(cg Cgroup) (MemorySlabStat, error) {
+	var stats MemorySlabStat
+
+	stats.SampleTime = time.Now()
+	stats.Caches = make(map[string]SlabCacheStat)
+
+	var memStats MemoryStat
+	err := populateMemoryStat(cg, &memStats)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Reclaimable = memStats.SlabReclaimable
+	stats.Unreclaimable = memStats.SlabUnreclaimable
+
+	filePath, err := GetCgroupPath(cg, ControllerMemory, "memory.kmem.slabinfo")
+	if err == ErrNoCgroup {
+		return stats, err
+	}
+
+	caches, err := readSlabinfo(cg, filePath)
+	if err != nil {
+		// Not an error on v2, there just is no per-cache breakdown.
+		return stats, nil
+	}
+
+	hostCaches, _ := readSlabinfo(cg, procPath(cg, "slabinfo"))
+
+	for name, cache := range caches {
+		if hostCache, ok := hostCaches[name]; ok && hostCache.Bytes != 0 {
+			cache.HostBytes = hostCache.Bytes
+			cache.HostPct = 100.0 * float64(cache.Bytes) / float64(hostCache.Bytes)
+		}
+
+		stats.Caches[name] = cache
+	}
+
+	return stats, nil
+}
+
+// readSlabinfo parses the slabinfo 2.1 format used by both /proc/slabinfo
+// and memory.kmem.slabinfo.
+func readSlabinfo(cg Cgroup, filePath string) (map[string]SlabCacheStat, error) {
+	fd, err := fsOpen(cg, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	caches := make(map[string]SlabCacheStat)
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "slabinfo") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		var cache SlabCacheStat
+		cache.Name = fields[0]
+
+		values := make([]uint64, 3)
+		for idx := range values {
+			values[idx], err = strconv.ParseUint(fields[idx+1], 10, 64)
+			if err != nil {
+				break
+			}
+		}
+
+		if err != nil {
+			continue
+		}
+
+		cache.ActiveObjs = values[0]
+		cache.NumObjs = values[1]
+		cache.ObjSize = values[2]
+		cache.Bytes = cache.NumObjs * cache.ObjSize
+
+		caches[cache.Name] = cache
+	}
+
+	return caches, nil
+}
@@ -0,0 +1,65 @@
+package cgroups
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestSlabinfoParse(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	contents := "slabinfo - version: 2.1\n" +
+		"# name            <active_objs> <num_objs> <objsize> <objperslab> <pagesperslab> : tunables <limit> <batchcount> <sharedfactor> : slabdata <active_slabs> <num_slabs> <sharedavail>\n" +
+		"dentry             1000   1050    192   21    1 : tunables    0    0    0 : slabdata     50     50      0\n" +
+		"inode_cache         100    120    600   13    2 : tunables    0    0    0 : slabdata     10     10      0\n"
+
+	filePath := path.Join(root, "slabinfo")
+	if err := ioutil.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	caches, err := readSlabinfo(Cgroup{}, filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(caches) != 2 || caches["dentry"].Bytes != 1050*192 {
+		t.Fail()
+	}
+
+	top := MemorySlabStat{Caches: caches}.TopCaches(1)
+
+	if len(top) != 1 || top[0].Name != "dentry" {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", caches)
+}
+
+func TestMemorySlabStats(t *testing.T) {
+	stats, err := GetMemorySlabStats(fixtureCgroup(fixtures.V1))
+
+	if err != nil {
+		t.Fail()
+	}
+
+	if len(stats.Caches) != 3 || stats.Caches["dentry"].HostPct != 10.0 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stats)
+
+	stats, err = GetMemorySlabStats(fixtureCgroup(fixtures.V2))
+
+	if err != nil || len(stats.Caches) != 0 || stats.Reclaimable != 12582912 {
+		t.Fail()
+	}
+}
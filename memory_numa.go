@@ -0,0 +1,163 @@
+package cgroups
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Per-node values are keyed by NUMA node ID. On v1 they are in pages,
+// on v2 in bytes; PageUnit tells which.
+type MemoryNumaStat struct {
+	Total                   map[int]uint64
+	File                    map[int]uint64
+	Anon                    map[int]uint64
+	Unevictable             map[int]uint64
+	HierarchicalTotal       map[int]uint64
+	HierarchicalFile        map[int]uint64
+	HierarchicalAnon        map[int]uint64
+	HierarchicalUnevictable map[int]uint64
+
+	// All keys from memory.numa_stat, including the ones above
+	Raw map[string]map[int]uint64
+
+	// Nodes the cgroup may allocate from, per cpuset.mems
+	CpusetMems []int
+
+	PageUnit bool
+
+	SampleTime time.Time
+}
+
+// Locality returns the fraction (0-1) of the cgroup's memory that lives
+// on the nodes in its cpuset.mems, or 1 if cpuset.mems is unknown.
+func (stats MemoryNumaStat) Locality() float64 {
+	if len(stats.CpusetMems) == 0 {
+		return 1.0
+	}
+
+	total := stats.HierarchicalTotal
+	if len(total) == 0 {
+		total = stats.Total
+	}
+
+	var local, all uint64
+	for node, value := range total {
+		all += value
+
+		for _, mem := range stats.CpusetMems {
+			if mem == node {
+				local += value
+				break
+			}
+		}
+	}
+
+	if all == 0 {
+		return 1.0
+	}
+
+	return float64(local) / float64(all)
+}
+
+func GetMemoryNumaStats(cg Cgroup) (MemoryNumaStat, error) {
+	var stats MemoryNumaStat
+
+	stats.SampleTime = time.Now()
+	stats.Raw = make(map[string]map[int]uint64)
+
+	path, err := GetCgroupPath(cg, ControllerMemory, "memory.numa_stat")
+	if err == ErrNoCgroup {
+		return stats, err
+	}
+
+	fd, err := fsOpen(cg, path)
+	if err != nil {
+		return stats, err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		name, nodes, isV1 := parseNumaStatLine(scanner.Text())
+		if name == "" {
+			continue
+		}
+
+		stats.PageUnit = isV1
+		stats.Raw[name] = nodes
+	}
+
+	stats.Total = stats.Raw["total"]
+	stats.File = stats.Raw["file"]
+	stats.Anon = stats.Raw["anon"]
+	stats.Unevictable = stats.Raw["unevictable"]
+	stats.HierarchicalTotal = stats.Raw["hierarchical_total"]
+	stats.HierarchicalFile = stats.Raw["hierarchical_file"]
+	stats.HierarchicalAnon = stats.Raw["hierarchical_anon"]
+	stats.HierarchicalUnevictable = stats.Raw["hierarchical_unevictable"]
+
+	// v2 has no total line; it is the sum of anon and file.
+	if stats.Total == nil && (stats.Anon != nil || stats.File != nil) {
+		stats.Total = sumNumaMaps(stats.Anon, stats.File)
+	}
+
+	if mems, err := GetCpusetMems(cg); err == nil {
+		stats.CpusetMems = mems
+	}
+
+	return stats, nil
+}
+
+// parseNumaStatLine parses either a v1 line ("total=N N0=x N1=y") or a v2
+// line ("anon N0=x N1=y"). The last return value is true for v1 lines.
+func parseNumaStatLine(line string) (string, map[int]uint64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 1 {
+		return "", nil, false
+	}
+
+	name := fields[0]
+	isV1 := false
+
+	if idx := strings.IndexByte(name, '='); idx >= 0 {
+		name = name[:idx]
+		isV1 = true
+	}
+
+	nodes := make(map[int]uint64)
+
+	for _, field := range fields[1:] {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "N") {
+			continue
+		}
+
+		node, err := strconv.Atoi(parts[0][1:])
+		if err != nil {
+			continue
+		}
+
+		value, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		nodes[node] = value
+	}
+
+	return name, nodes, isV1
+}
+
+func sumNumaMaps(maps ...map[int]uint64) map[int]uint64 {
+	sum := make(map[int]uint64)
+
+	for _, m := range maps {
+		for node, value := range m {
+			sum[node] += value
+		}
+	}
+
+	return sum
+}
@@ -0,0 +1,54 @@
+package cgroups
+
+import (
+	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestNumaStatParse(t *testing.T) {
+	name, nodes, isV1 := parseNumaStatLine("hierarchical_total=300 N0=100 N1=200")
+
+	if name != "hierarchical_total" || !isV1 || nodes[0] != 100 || nodes[1] != 200 {
+		t.Fail()
+	}
+
+	name, nodes, isV1 = parseNumaStatLine("anon N0=4096 N1=8192")
+
+	if name != "anon" || isV1 || nodes[0] != 4096 || nodes[1] != 8192 {
+		t.Fail()
+	}
+}
+
+func TestNumaLocality(t *testing.T) {
+	stats := MemoryNumaStat{
+		HierarchicalTotal: map[int]uint64{0: 300, 1: 100},
+		CpusetMems:        []int{0},
+	}
+
+	if stats.Locality() != 0.75 {
+		t.Fail()
+	}
+
+	stats.CpusetMems = nil
+
+	if stats.Locality() != 1.0 {
+		t.Fail()
+	}
+}
+
+func TestMemoryNumaStats(t *testing.T) {
+	for name, fsys := range fixtures.All {
+		stats, err := GetMemoryNumaStats(fixtureCgroup(fsys))
+
+		if err != nil {
+			t.Fail()
+		}
+
+		if len(stats.Total) != 2 || stats.Locality() != 0.75 {
+			t.Errorf("%s: %+v\n", name, stats)
+		}
+
+		t.Logf("%s: %+v\n", name, stats)
+	}
+}
@@ -0,0 +1,111 @@
+package cgroups
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	ErrReclaimTooLarge = errors.New("go-cgroups: Cannot reclaim all of the memory usage")
+)
+
+// Reclaim asks the kernel to proactively reclaim the given number of
+// bytes from the cgroup through memory.reclaim (v2, 5.19+) and returns
+// how much usage actually dropped. The kernel fails the write with EAGAIN
+// if it could not reclaim the full amount; the partial result is still
+// returned in that case.
+func Reclaim(cg Cgroup, bytes uint64) (uint64, error) {
+	path, err := GetCgroupPath(cg, ControllerMemory, "memory.reclaim")
+	if err != nil {
+		return 0, err
+	}
+
+	before, err := getMemoryUsage(cg)
+	if err != nil {
+		return 0, err
+	}
+
+	writeErr := fsWriteFile(cg, path, []byte(strconv.FormatUint(bytes, 10)))
+
+	after, err := getMemoryUsage(cg)
+	if err != nil {
+		return 0, err
+	}
+
+	return counterDelta(before, after), writeErr
+}
+
+// ForceEmpty reclaims as much memory as possible from the cgroup through
+// memory.force_empty (v1 only) and returns how much usage dropped.
+func ForceEmpty(cg Cgroup) (uint64, error) {
+	path, err := GetCgroupPath(cg, ControllerMemory, "memory.force_empty")
+	if err != nil {
+		return 0, err
+	}
+
+	before, err := getMemoryUsage(cg)
+	if err != nil {
+		return 0, err
+	}
+
+	err = fsWriteFile(cg, path, []byte("0"))
+	if err != nil {
+		return 0, err
+	}
+
+	after, err := getMemoryUsage(cg)
+	if err != nil {
+		return 0, err
+	}
+
+	return counterDelta(before, after), nil
+}
+
+// DropByHigh reclaims the given number of bytes by temporarily lowering
+// memory.high (v2) below the current usage, which makes the kernel
+// reclaim synchronously, and then restoring the previous value. It is
+// meant for kernels without memory.reclaim. Asking for the whole usage
+// fails with ErrReclaimTooLarge, as memory.high=0 would reclaim
+// everything the cgroup has.
+func DropByHigh(cg Cgroup, bytes uint64) (uint64, error) {
+	high, path, err := readMemoryConfigFile(cg, "memory.high")
+	if err != nil {
+		return 0, err
+	}
+
+	before, err := getMemoryUsage(cg)
+	if err != nil {
+		return 0, err
+	}
+
+	if bytes >= before {
+		return 0, ErrReclaimTooLarge
+	}
+
+	err = fsWriteFile(cg, path, []byte(formatMemoryValue(path, before-bytes)))
+	if err != nil {
+		return 0, err
+	}
+
+	after, usageErr := getMemoryUsage(cg)
+
+	err = fsWriteFile(cg, path, []byte(high))
+	if err != nil {
+		return 0, err
+	}
+
+	if usageErr != nil {
+		return 0, usageErr
+	}
+
+	return counterDelta(before, after), nil
+}
+
+func getMemoryUsage(cg Cgroup) (uint64, error) {
+	contents, _, err := readMemoryConfigFile(cg, "memory.usage_in_bytes,memory.current")
+	if err != nil {
+		return 0, err
+	}
+
+	return parseMemoryValue(contents)
+}
@@ -0,0 +1,159 @@
+package cgroups
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestDropByHigh(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := ioutil.WriteFile(path.Join(root, "memory.current"), []byte("10000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(root, "memory.high"), []byte("max\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is actually reclaimed from a regular file, but the
+	// previous memory.high must be restored.
+	freed, err := DropByHigh(Cgroup{Root: root, Cgroup: "/"}, 4000)
+
+	if err != nil || freed != 0 {
+		t.Fail()
+	}
+
+	contents, _ := ioutil.ReadFile(path.Join(root, "memory.high"))
+	if string(contents) != "max" {
+		t.Fail()
+	}
+}
+
+// reclaimFS overlays writable files on a fixture tree, and reclaims from
+// the usage file on writes to memory.reclaim, memory.force_empty and
+// memory.high the way the kernel does.
+type reclaimFS struct {
+	fs.FS
+	usage string
+	files fstest.MapFS
+}
+
+func newReclaimFS(fsys fs.FS, usage string) reclaimFS {
+	contents, err := fs.ReadFile(fsys, usage)
+	if err != nil {
+		panic(err)
+	}
+
+	return reclaimFS{fsys, usage, fstest.MapFS{usage: {Data: contents}}}
+}
+
+func (r reclaimFS) Open(name string) (fs.File, error) {
+	if _, ok := r.files[name]; ok {
+		return r.files.Open(name)
+	}
+
+	return r.FS.Open(name)
+}
+
+func (r reclaimFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	usage, err := strconv.ParseUint(strings.TrimSpace(string(r.files[r.usage].Data)), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	var reclaimErr error
+
+	switch path.Base(name) {
+	case "memory.reclaim":
+		bytes, err := strconv.ParseUint(string(data), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		if bytes > usage {
+			bytes, reclaimErr = usage, syscall.EAGAIN
+		}
+		usage -= bytes
+	case "memory.force_empty":
+		usage = 0
+	case "memory.high":
+		if high, err := strconv.ParseUint(string(data), 10, 64); err == nil && high < usage {
+			usage = high
+		}
+	}
+
+	r.files[r.usage] = &fstest.MapFile{Data: []byte(strconv.FormatUint(usage, 10))}
+	r.files[name] = &fstest.MapFile{Data: data}
+
+	return reclaimErr
+}
+
+func TestReclaim(t *testing.T) {
+	fsys := newReclaimFS(fixtures.V2, "sys/fs/cgroup/system.slice/memory.current")
+	cg := fixtureCgroup(fsys)
+
+	freed, err := Reclaim(cg, 1048576)
+	if err != nil || freed != 1048576 {
+		t.Errorf("%d %v\n", freed, err)
+	}
+
+	// Asking for more than there is reclaims what it can, and returns
+	// the kernel's error.
+	freed, err = Reclaim(cg, 1<<40)
+	if err != syscall.EAGAIN || freed != 402653184-1048576 {
+		t.Errorf("%d %v\n", freed, err)
+	}
+
+	if _, err := Reclaim(fixtureCgroup(fixtures.V1), 1048576); err != ErrReadOnlyFS {
+		t.Errorf("%v\n", err)
+	}
+}
+
+func TestForceEmpty(t *testing.T) {
+	for _, fsys := range []fs.FS{fixtures.V1, fixtures.Hybrid} {
+		cg := fixtureCgroup(newReclaimFS(fsys, "sys/fs/cgroup/memory/system.slice/memory.usage_in_bytes"))
+
+		freed, err := ForceEmpty(cg)
+		if err != nil || freed != 402653184 {
+			t.Errorf("%d %v\n", freed, err)
+		}
+	}
+}
+
+func TestDropByHighFixture(t *testing.T) {
+	fsys := newReclaimFS(fixtures.V2, "sys/fs/cgroup/system.slice/memory.current")
+	cg := fixtureCgroup(fsys)
+
+	high, _ := fs.ReadFile(fsys, "sys/fs/cgroup/system.slice/memory.high")
+
+	freed, err := DropByHigh(cg, 1048576)
+	if err != nil || freed != 1048576 {
+		t.Errorf("%d %v\n", freed, err)
+	}
+
+	if freed, err := DropByHigh(cg, 1<<40); err != ErrReclaimTooLarge || freed != 0 {
+		t.Errorf("%d %v\n", freed, err)
+	}
+
+	// Only memory.high is written, and it is restored afterwards
+	for name, file := range fsys.files {
+		if name != fsys.usage && path.Base(name) != "memory.high" {
+			t.Errorf("unexpected write to %s\n", name)
+		} else if path.Base(name) == "memory.high" && string(file.Data) != strings.TrimSpace(string(high)) {
+			t.Errorf("memory.high not restored: %q\n", file.Data)
+		}
+	}
+}
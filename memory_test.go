@@ -1,11 +1,24 @@
 package cgroups
 
 import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
+	"time"
+
+	"github.com/bwalex/go-cgroups/fixtures"
 )
 
 func TestMemoryStat(t *testing.T) {
-	stats, err := GetMemoryStats(Cgroup{ Cgroup: "/system.slice" })
+	for _, fsys := range []fs.FS{fixtures.V1, fixtures.Hybrid} {
+		testMemoryStat(t, fsys)
+	}
+}
+
+func testMemoryStat(t *testing.T, fsys fs.FS) {
+	stats, err := GetMemoryStats(fixtureCgroup(fsys))
 
 	if err != nil {
 		t.Fail()
@@ -38,9 +51,188 @@ func TestMemoryStat(t *testing.T) {
 	t.Logf("%+v\n", stats)
 }
 
+func TestMemoryStatDerived(t *testing.T) {
+	stats := MemoryStat{
+		MemUsage:          800,
+		MemLimit:          1000,
+		TotalInactiveFile: 300,
+		MemSwapUsage:      900,
+		MemSwapLimit:      MemoryUnlimited,
+	}
+
+	if stats.WorkingSet() != 500 {
+		t.Fail()
+	}
+
+	if stats.UsagePct() != 80.0 {
+		t.Fail()
+	}
+
+	if headroom, ok := stats.Headroom(); !ok || headroom != 200 {
+		t.Fail()
+	}
+
+	if _, ok := stats.SwapHeadroom(); ok {
+		t.Fail()
+	}
+
+	stats.MemLimit = MemoryUnlimited
+
+	if _, ok := stats.Headroom(); ok {
+		t.Fail()
+	}
+
+	if stats.UsagePct() != 0 {
+		t.Fail()
+	}
+
+	value, err := parseMemoryValue("max")
+	if err != nil || value != MemoryUnlimited {
+		t.Fail()
+	}
+}
+
+func TestMemoryDelta(t *testing.T) {
+	now := time.Now()
+
+	prev := MemoryStat{
+		PgFault:               1000,
+		PgMajFault:            10,
+		TotalPgMajFault:       20,
+		WorkingsetRefaultAnon: 5,
+		WorkingsetRefaultFile: 5,
+		MemFailCnt:            3,
+		MemUsage:              4096,
+		SampleTime:            now,
+	}
+
+	cur := MemoryStat{
+		PgFault:               3000,
+		PgMajFault:            12,
+		TotalPgMajFault:       30,
+		WorkingsetRefaultAnon: 7,
+		WorkingsetRefaultFile: 9,
+		MemFailCnt:            4,
+		MemUsage:              0,
+		SampleTime:            now.Add(2 * time.Second),
+	}
+
+	delta := cur.Delta(prev)
+
+	if delta.PgFaultRate != 1000 || delta.PgMajFaultRate != 5.0 {
+		t.Fail()
+	}
+
+	if delta.WorkingsetRefaultRate != 3.0 || delta.FailCntIncrease != 1 {
+		t.Fail()
+	}
+
+	if delta.UsageGrowthRate != -2048.0 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", delta)
+}
+
+func TestMemoryDeltaSameTime(t *testing.T) {
+	now := time.Now()
+
+	prev := MemoryStat{PgFault: 1000, SampleTime: now}
+	cur := MemoryStat{PgFault: 2000, SampleTime: now}
+
+	if delta := CalcMemoryDeltaStats(cur, prev); delta != (MemoryDeltaStat{}) {
+		t.Errorf("%+v\n", delta)
+	}
+
+	if delta := CalcMemoryDeltaStats(MemoryStat{}, MemoryStat{}); delta != (MemoryDeltaStat{}) {
+		t.Errorf("%+v\n", delta)
+	}
+}
+
+func TestMemoryStatV2(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	contents := "anon 1000\nfile 2000\nkernel 300\nsock 42\nfile_mapped 7\nfile_dirty 8\npgscan 12\n"
+	if err := ioutil.WriteFile(path.Join(root, "memory.stat"), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(root, "memory.max"), []byte("max\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := GetMemoryStats(Cgroup{Root: root, Cgroup: "/"})
+
+	if err != nil {
+		t.Fail()
+	}
+
+	if stats.RSS != 1000 || stats.Cache != 2000 || stats.MappedFile != 7 || stats.Dirty != 8 {
+		t.Fail()
+	}
+
+	if stats.Kernel != 300 || stats.Sock != 42 || stats.PgScan != 12 {
+		t.Fail()
+	}
+
+	if stats.MemLimit != MemoryUnlimited || stats.HasLimit() {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stats)
+}
+
+func TestMemoryStatV2Fixture(t *testing.T) {
+	stats, err := GetMemoryStats(fixtureCgroup(fixtures.V2))
+
+	if err != nil {
+		t.Fail()
+	}
+
+	if stats.RSS != 134217728 || stats.Cache != 268435456 || stats.RSSHuge != 16777216 || stats.PgMajFault != 1536 {
+		t.Fail()
+	}
+
+	if stats.MemUsage != 402653184 || stats.MemUsageMax != 536870912 || stats.MemLimit != 1073741824 {
+		t.Fail()
+	}
+
+	if stats.SwapUsage != 4194304 || stats.SwapLimit != MemoryUnlimited || stats.WorkingSet() != 268435456 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stats)
+}
+
+func TestMemoryUnlimitedPageSizes(t *testing.T) {
+	// 4K and 64K pages
+	for _, contents := range []string{"9223372036854771712", "9223372036854710272", "max"} {
+		value, err := parseMemoryValue(contents)
+		if err != nil || value != MemoryUnlimited {
+			t.Errorf("%s: %d %v\n", contents, value, err)
+		}
+	}
+
+	stats := MemoryStat{MemUsage: 1024, MemLimit: 9223372036854710272}
+	if stats.HasLimit() || stats.UsagePct() != 0 {
+		t.Errorf("%+v\n", stats)
+	}
+
+	if _, ok := stats.Headroom(); ok {
+		t.Fail()
+	}
+
+	if formatMemoryValue("memory.limit_in_bytes", 9223372036854710272) != "-1" {
+		t.Fail()
+	}
+}
+
 func BenchmarkMemoryStat(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := GetMemoryStats(Cgroup{ Cgroup: "/system.slice" })
+		_, err := GetMemoryStats(fixtureCgroup(fixtures.V1))
 		if err != nil {
 			b.Fail()
 		}
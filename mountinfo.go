@@ -0,0 +1,129 @@
+package cgroups
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// CgroupMount is a cgroup filesystem mount, as listed in
+// /proc/<pid>/mountinfo.
+type CgroupMount struct {
+	Mountpoint  string   // e.g.: /sys/fs/cgroup/memory
+	Root        string   // cgroup mounted there, usually "/"
+	Version     int      // 1 or 2
+	Controllers []string // v1 only, e.g.: cpu, cpuacct or name=systemd
+}
+
+// Mount options of v1 cgroups that are not controllers
+var cgroupMountOptions = map[string]bool{
+	"rw":             true,
+	"ro":             true,
+	"all":            true,
+	"none":           true,
+	"noprefix":       true,
+	"xattr":          true,
+	"clone_children": true,
+	"cpuset_v2_mode": true,
+	"favordynmods":   true,
+}
+
+// GetCgroupMounts returns the cgroup mounts of the caller, from
+// /proc/self/mountinfo, so their mountpoints can be used as Cgroup.Root.
+func GetCgroupMounts() ([]CgroupMount, error) {
+	return Host{}.GetCgroupMounts()
+}
+
+// readCgroupMounts reads the mounts of the caller, or with a ProcRoot set
+// those of PID 1 in it: "self" in a host's /proc mounted elsewhere is
+// still the caller, in its own mount namespace.
+func readCgroupMounts(cg Cgroup) ([]CgroupMount, error) {
+	pid := "self"
+	if cg.ProcRoot != "" {
+		pid = "1"
+	}
+
+	fd, err := fsOpen(cg, procPath(cg, pid, "mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	return parseMountinfo(bufio.NewScanner(fd))
+}
+
+func parseMountinfo(scanner *bufio.Scanner) ([]CgroupMount, error) {
+	mounts := make([]CgroupMount, 0)
+
+	for scanner.Scan() {
+		// id parent major:minor root mountpoint options [optional...] - fstype source super-options
+		fields := strings.Fields(scanner.Text())
+
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+
+		if sep < 0 || len(fields) < sep+4 {
+			return nil, ErrInvalidFormat
+		}
+
+		mount := CgroupMount{
+			Mountpoint: unescapeMountinfo(fields[4]),
+			Root:       unescapeMountinfo(fields[3]),
+		}
+
+		switch fields[sep+1] {
+		case "cgroup2":
+			mount.Version = 2
+		case "cgroup":
+			mount.Version = 1
+			mount.Controllers = make([]string, 0)
+
+			for _, opt := range strings.Split(fields[sep+3], ",") {
+				if cgroupMountOptions[opt] || (strings.Contains(opt, "=") && !strings.HasPrefix(opt, "name=")) {
+					continue
+				}
+
+				mount.Controllers = append(mount.Controllers, opt)
+			}
+		default:
+			continue
+		}
+
+		mounts = append(mounts, mount)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return mounts, nil
+}
+
+// unescapeMountinfo undoes the octal escaping of spaces, tabs, newlines
+// and backslashes in mountinfo paths.
+func unescapeMountinfo(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package cgroups
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestCgroupMounts(t *testing.T) {
+	expected := map[string]int{"v1": 7, "hybrid": 7, "v2": 1}
+
+	for name, fsys := range fixtures.All {
+		mounts, err := Host{FS: fsys}.GetCgroupMounts()
+		if err != nil || len(mounts) != expected[name] {
+			t.Errorf("%s: %+v %v\n", name, mounts, err)
+			continue
+		}
+
+		for _, mount := range mounts {
+			if mount.Root != "/" || (mount.Version == 2) != (len(mount.Controllers) == 0) {
+				t.Errorf("%s: %+v\n", name, mount)
+			}
+
+			if len(mount.Controllers) == 1 && mount.Mountpoint != "/sys/fs/cgroup/"+strings.TrimPrefix(mount.Controllers[0], "name=") {
+				t.Errorf("%s: %+v\n", name, mount)
+			}
+		}
+
+		t.Logf("%+v\n", mounts)
+	}
+
+	mounts, err := Host{ProcRoot: "/host/proc", FS: hostFS{fixtures.V2}}.GetCgroupMounts()
+	if err != nil || len(mounts) != 1 || mounts[0].Mountpoint != DefaultSysfsRoot {
+		t.Errorf("%+v %v\n", mounts, err)
+	}
+
+	// In a container, self is the container's namespace and 1 the host's
+	fsys := fstest.MapFS{
+		"host/proc/self/mountinfo": {Data: []byte("30 25 0:27 /docker/abc /sys/fs/cgroup ro - cgroup2 cgroup2 rw\n")},
+		"host/proc/1/mountinfo":    {Data: []byte("25 24 0:23 / /sys/fs/cgroup rw - cgroup2 cgroup2 rw\n")},
+	}
+
+	mounts, err = Host{ProcRoot: "/host/proc", FS: fsys}.GetCgroupMounts()
+	if err != nil || len(mounts) != 1 || mounts[0].Root != "/" {
+		t.Errorf("%+v %v\n", mounts, err)
+	}
+}
+
+func TestParseMountinfo(t *testing.T) {
+	contents := `30 25 0:27 /docker/abc /sys/fs/cgroup/cpu,cpuacct rw,nosuid - cgroup cgroup rw,cpu,cpuacct,clone_children
+31 25 0:28 / /mnt/cgroup\040v2 rw master:1 shared:9 - cgroup2 none rw
+32 22 8:1 / /boot rw - ext4 /dev/sda1 rw
+`
+
+	mounts, err := parseMountinfo(bufio.NewScanner(strings.NewReader(contents)))
+	if err != nil || len(mounts) != 2 {
+		t.Fatalf("%+v %v\n", mounts, err)
+	}
+
+	if mounts[0].Root != "/docker/abc" || mounts[0].Version != 1 || strings.Join(mounts[0].Controllers, ",") != "cpu,cpuacct" {
+		t.Errorf("%+v\n", mounts[0])
+	}
+
+	if mounts[1].Mountpoint != "/mnt/cgroup v2" || mounts[1].Version != 2 {
+		t.Errorf("%+v\n", mounts[1])
+	}
+
+	if _, err := parseMountinfo(bufio.NewScanner(strings.NewReader("1 2 3\n"))); err != ErrInvalidFormat {
+		t.Fail()
+	}
+}
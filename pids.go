@@ -0,0 +1,45 @@
+package cgroups
+
+import (
+	"strconv"
+	"time"
+)
+
+type PidsStat struct {
+	// From pids.current, pids.max and pids.events
+	Current uint64 `json:"current,omitempty"`
+	Limit   uint64 `json:"limit,omitempty"`      /* 0 if unlimited */
+	Max     uint64 `json:"max_events,omitempty"` /* number of times a fork failed due to the limit */
+
+	SampleTime time.Time `json:"-"`
+}
+
+const (
+	ControllerPids = "pids"
+)
+
+func GetPidsStats(cg Cgroup) (PidsStat, error) {
+	var stats PidsStat
+
+	stats.SampleTime = time.Now()
+
+	value, err := readCgroupFile(cg, ControllerPids, "pids.current")
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Current, err = strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return stats, err
+	}
+
+	if value, err := readCgroupFile(cg, ControllerPids, "pids.max"); err == nil && value != "max" {
+		stats.Limit, _ = strconv.ParseUint(value, 10, 64)
+	}
+
+	if value, err := readCgroupFile(cg, ControllerPids, "pids.events"); err == nil {
+		stats.Max, _ = strconv.ParseUint(keyedValue(value, "max"), 10, 64)
+	}
+
+	return stats, nil
+}
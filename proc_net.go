@@ -2,8 +2,6 @@ package cgroups
 
 import (
 	"bufio"
-	"os"
-	"path"
 	"reflect"
 	"strconv"
 	"strings"
@@ -11,25 +9,25 @@ import (
 )
 
 type NetStat struct {
-	RxBytes      uint64 `field:"0"`
-	RxPackets    uint64 `field:"1"`
-	RxErrors     uint64 `field:"2"`
-	RxDrop       uint64 `field:"3"`
-	RxFifo       uint64 `field:"4"`
-	RxFrame      uint64 `field:"5"`
-	RxCompressed uint64 `field:"6"`
-	RxMulticast  uint64 `field:"7"`
-
-	TxBytes      uint64 `field:"8"`
-	TxPackets    uint64 `field:"9"`
-	TxErrors     uint64 `field:"10"`
-	TxDrop       uint64 `field:"11"`
-	TxFifo       uint64 `field:"12"`
-	TxFrame      uint64 `field:"13"`
-	TxCompressed uint64 `field:"14"`
-	TxMulticast  uint64 `field:"15"`
-
-	SampleTime   time.Time
+	RxBytes      uint64 `field:"0" json:"rx_bytes,omitempty"`
+	RxPackets    uint64 `field:"1" json:"rx_packets,omitempty"`
+	RxErrors     uint64 `field:"2" json:"rx_errors,omitempty"`
+	RxDrop       uint64 `field:"3" json:"rx_drop,omitempty"`
+	RxFifo       uint64 `field:"4" json:"rx_fifo,omitempty"`
+	RxFrame      uint64 `field:"5" json:"rx_frame,omitempty"`
+	RxCompressed uint64 `field:"6" json:"rx_compressed,omitempty"`
+	RxMulticast  uint64 `field:"7" json:"rx_multicast,omitempty"`
+
+	TxBytes      uint64 `field:"8" json:"tx_bytes,omitempty"`
+	TxPackets    uint64 `field:"9" json:"tx_packets,omitempty"`
+	TxErrors     uint64 `field:"10" json:"tx_errors,omitempty"`
+	TxDrop       uint64 `field:"11" json:"tx_drop,omitempty"`
+	TxFifo       uint64 `field:"12" json:"tx_fifo,omitempty"`
+	TxFrame      uint64 `field:"13" json:"tx_frame,omitempty"`
+	TxCompressed uint64 `field:"14" json:"tx_compressed,omitempty"`
+	TxMulticast  uint64 `field:"15" json:"tx_multicast,omitempty"`
+
+	SampleTime   time.Time `json:"-"`
 }
 
 type NetItemizedStats struct {
@@ -37,14 +35,40 @@ type NetItemizedStats struct {
 }
 
 type NetDeltaStat struct {
-	RxByteRate   uint64
-	RxPacketRate uint64
-	RxDropRate   float64
-	RxErrorRate  float64
-	TxByteRate   uint64
-	TxPacketRate uint64
-	TxDropRate   float64
-	TxErrorRate  float64
+	RxByteRate   uint64  `json:"rx_bytes_per_sec,omitempty"`
+	RxPacketRate uint64  `json:"rx_packets_per_sec,omitempty"`
+	RxDropRate   float64 `json:"rx_drops_per_sec,omitempty"`
+	RxErrorRate  float64 `json:"rx_errors_per_sec,omitempty"`
+	TxByteRate   uint64  `json:"tx_bytes_per_sec,omitempty"`
+	TxPacketRate uint64  `json:"tx_packets_per_sec,omitempty"`
+	TxDropRate   float64 `json:"tx_drops_per_sec,omitempty"`
+	TxErrorRate  float64 `json:"tx_errors_per_sec,omitempty"`
+}
+
+// Total sums the stats of all interfaces except lo, like GetNetStats
+// with an empty interface name.
+func (stats NetItemizedStats) Total() NetStat {
+	var total NetStat
+
+	for intf, stat := range stats.Stats {
+		if intf == "lo" {
+			continue
+		}
+
+		tv := reflect.ValueOf(&total).Elem()
+		sv := reflect.ValueOf(stat)
+		for i := 0; i < tv.NumField(); i++ {
+			if tv.Type().Field(i).Tag.Get("field") == "" {
+				continue
+			}
+
+			tv.Field(i).SetUint(tv.Field(i).Uint() + sv.Field(i).Uint())
+		}
+
+		total.SampleTime = stat.SampleTime
+	}
+
+	return total
 }
 
 func (stats NetStat) Delta(prevStats NetStat) NetDeltaStat {
@@ -215,7 +239,7 @@ func procNetDevLines(cg Cgroup) ([]string, error) {
 
 	pid := strconv.Itoa(pids[0])
 
-	fd, err := os.Open(path.Join("/proc", pid, "net/dev"))
+	fd, err := fsOpen(cg, procPath(cg, pid, "net/dev"))
 	if err != nil {
 		return lines, err
 	}
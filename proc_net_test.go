@@ -2,16 +2,18 @@ package cgroups
 
 import (
 	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
 )
 
 func TestProcNetInterfaces(t *testing.T) {
-	devs, err := GetNetInterfaces(Cgroup{ Cgroup: "/system.slice" })
+	devs, err := GetNetInterfaces(fixtureCgroup(fixtures.V1))
 
 	if err != nil {
 		t.Fail()
 	}
 
-	if len(devs) < 1 {
+	if len(devs) != 2 {
 		t.Fail()
 	}
 
@@ -19,7 +21,7 @@ func TestProcNetInterfaces(t *testing.T) {
 }
 
 func TestNetStatSingle(t *testing.T) {
-	stats, err := GetNetStats(Cgroup{ Cgroup: "/system.slice" }, "lo")
+	stats, err := GetNetStats(fixtureCgroup(fixtures.V1), "lo")
 
 	if err != nil {
 		t.Fail()
@@ -45,7 +47,7 @@ func TestNetStatSingle(t *testing.T) {
 }
 
 func TestNetStatTotals(t *testing.T) {
-	stats, err := GetNetStats(Cgroup{ Cgroup: "/system.slice" }, "")
+	stats, err := GetNetStats(fixtureCgroup(fixtures.V1), "")
 
 	if err != nil {
 		t.Fail()
@@ -71,18 +73,26 @@ func TestNetStatTotals(t *testing.T) {
 }
 
 func TestNetStatItemized(t *testing.T) {
-	stats, err := GetNetItemizedStats(Cgroup{ Cgroup: "/system.slice" })
+	stats, err := GetNetItemizedStats(fixtureCgroup(fixtures.V1))
 
 	if err != nil {
 		t.Fail()
 	}
 
+	if stats.Stats["eth0"].RxBytes != 52428800 || stats.Stats["eth0"].TxDrop != 1 || stats.Stats["lo"].RxPackets != 1024 {
+		t.Fail()
+	}
+
+	if stats.Total().RxBytes != 52428800 {
+		t.Fail()
+	}
+
 	t.Logf("%+v\n", stats)
 }
 
 func BenchmarkNetStatTotals(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := GetNetStats(Cgroup{ Cgroup: "/system.slice" }, "")
+		_, err := GetNetStats(fixtureCgroup(fixtures.V1), "")
 		if err != nil {
 			b.Fail()
 		}
@@ -91,7 +101,7 @@ func BenchmarkNetStatTotals(b *testing.B) {
 
 func BenchmarkNetStatItemized(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := GetNetItemizedStats(Cgroup{ Cgroup: "/system.slice" })
+		_, err := GetNetItemizedStats(fixtureCgroup(fixtures.V1))
 		if err != nil {
 			b.Fail()
 		}
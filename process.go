@@ -0,0 +1,252 @@
+package cgroups
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A process is identified by its PID together with its start time (in
+// clock ticks since boot), so that PID reuse between two samples is not
+// mistaken for the same process.
+type ProcessKey struct {
+	Pid       int
+	StartTime uint64
+}
+
+type ProcessStat struct {
+	Pid       int
+	StartTime uint64 /* in clock ticks since boot */
+
+	// From /proc/<pid>/stat
+	Comm         string
+	State        string
+	RSS          uint64 /* in bytes */
+	UserTimeUs   uint64 /* in microseconds */
+	SystemTimeUs uint64 /* in microseconds */
+
+	// From /proc/<pid>/status
+	Threads                uint64 `status:"Threads"`
+	VoluntaryCtxSwitches   uint64 `status:"voluntary_ctxt_switches"`
+	InvoluntaryCtxSwitches uint64 `status:"nonvoluntary_ctxt_switches"`
+
+	// From /proc/<pid>/io
+	RChar      uint64 `io:"rchar"`
+	WChar      uint64 `io:"wchar"`
+	ReadBytes  uint64 `io:"read_bytes"`
+	WriteBytes uint64 `io:"write_bytes"`
+
+	SampleTime time.Time
+}
+
+type ProcessItemizedStats struct {
+	Stats map[ProcessKey]ProcessStat
+}
+
+type ProcessDeltaStat struct {
+	UsagePct                 float64
+	UserUsagePct             float64
+	SystemUsagePct           float64
+	VoluntaryCtxSwitchRate   float64
+	InvoluntaryCtxSwitchRate float64
+	RCharRate                uint64
+	WCharRate                uint64
+	ReadByteRate             uint64
+	WriteByteRate            uint64
+}
+
+func (stats ProcessStat) Key() ProcessKey {
+	return ProcessKey{Pid: stats.Pid, StartTime: stats.StartTime}
+}
+
+func (stats ProcessStat) Delta(prevStats ProcessStat) ProcessDeltaStat {
+	return CalcProcessDeltaStats(stats, prevStats)
+}
+
+// Delta only reports processes present in both samples; a PID that was
+// reused in between has a different key and is therefore skipped.
+func (stats ProcessItemizedStats) Delta(prevStats ProcessItemizedStats) map[ProcessKey]ProcessDeltaStat {
+	deltas := make(map[ProcessKey]ProcessDeltaStat)
+
+	for key, stat := range stats.Stats {
+		prevStat, ok := prevStats.Stats[key]
+		if !ok {
+			continue
+		}
+
+		deltas[key] = stat.Delta(prevStat)
+	}
+
+	return deltas
+}
+
+func CalcProcessDeltaStats(stats ProcessStat, prevStats ProcessStat) ProcessDeltaStat {
+	var deltaStat ProcessDeltaStat
+
+	userTimeDeltaUs := stats.UserTimeUs - prevStats.UserTimeUs
+	systemTimeDeltaUs := stats.SystemTimeUs - prevStats.SystemTimeUs
+	volDelta := stats.VoluntaryCtxSwitches - prevStats.VoluntaryCtxSwitches
+	involDelta := stats.InvoluntaryCtxSwitches - prevStats.InvoluntaryCtxSwitches
+	rcharDelta := stats.RChar - prevStats.RChar
+	wcharDelta := stats.WChar - prevStats.WChar
+	rdByteDelta := stats.ReadBytes - prevStats.ReadBytes
+	wrByteDelta := stats.WriteBytes - prevStats.WriteBytes
+
+	timeDeltaUs := stats.SampleTime.Sub(prevStats.SampleTime).Nanoseconds() / int64(time.Microsecond)
+	timeDeltaMs := uint64(timeDeltaUs / 1000)
+
+	// Samples less than a millisecond apart have no meaningful rates
+	if timeDeltaMs == 0 {
+		return deltaStat
+	}
+
+	deltaStat.UserUsagePct = 100.0 * float64(userTimeDeltaUs) / float64(timeDeltaUs)
+	deltaStat.SystemUsagePct = 100.0 * float64(systemTimeDeltaUs) / float64(timeDeltaUs)
+	deltaStat.UsagePct = 100.0 * float64(userTimeDeltaUs+systemTimeDeltaUs) / float64(timeDeltaUs)
+
+	deltaStat.VoluntaryCtxSwitchRate = float64(volDelta*1000) / float64(timeDeltaMs)
+	deltaStat.InvoluntaryCtxSwitchRate = float64(involDelta*1000) / float64(timeDeltaMs)
+	deltaStat.RCharRate = (rcharDelta * 1000) / timeDeltaMs
+	deltaStat.WCharRate = (wcharDelta * 1000) / timeDeltaMs
+	deltaStat.ReadByteRate = (rdByteDelta * 1000) / timeDeltaMs
+	deltaStat.WriteByteRate = (wrByteDelta * 1000) / timeDeltaMs
+
+	return deltaStat
+}
+
+func GetProcessStats(cg Cgroup) (ProcessItemizedStats, error) {
+	var stats ProcessItemizedStats
+	stats.Stats = make(map[ProcessKey]ProcessStat)
+
+	pids, err := GetProcs(cg)
+	if err != nil {
+		return stats, err
+	}
+
+	for i := range pids {
+		stat, err := getProcessStat(cg, pids[i])
+		if err != nil {
+			// The process most likely exited in the meantime.
+			continue
+		}
+
+		stats.Stats[stat.Key()] = stat
+	}
+
+	return stats, nil
+}
+
+func GetProcessStat(pid int) (ProcessStat, error) {
+	return Host{}.GetProcessStat(pid)
+}
+
+func getProcessStat(cg Cgroup, pid int) (ProcessStat, error) {
+	var stat ProcessStat
+
+	stat.Pid = pid
+	stat.SampleTime = time.Now()
+
+	err := populateProcessStat(cg, pid, &stat)
+	if err != nil {
+		return stat, err
+	}
+
+	// status and io are best-effort; io in particular is only readable
+	// by the owner of the process or a privileged user.
+	populateProcessKeyed(cg, pid, "status", "status", &stat)
+	populateProcessKeyed(cg, pid, "io", "io", &stat)
+
+	return stat, nil
+}
+
+func populateProcessStat(cg Cgroup, pid int, stat *ProcessStat) error {
+	contentsRaw, err := fsReadFile(cg, procPath(cg, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return err
+	}
+
+	return parseProcessStat(string(contentsRaw), stat)
+}
+
+// parseProcessStat parses the contents of /proc/<pid>/stat. The comm
+// field is enclosed in parentheses and may itself contain spaces and
+// parentheses, so the remaining fields are split after the last ')'.
+func parseProcessStat(contents string, stat *ProcessStat) error {
+	start := strings.IndexByte(contents, '(')
+	end := strings.LastIndexByte(contents, ')')
+	if start < 0 || end < start {
+		return ErrInvalidFormat
+	}
+
+	stat.Comm = contents[start+1 : end]
+
+	// Field indices below are relative to the state field, i.e. field 3
+	// in proc(5) numbering.
+	fields := strings.Fields(contents[end+1:])
+	if len(fields) < 22 {
+		return ErrInvalidFormat
+	}
+
+	stat.State = fields[0]
+
+	values := make([]uint64, len(fields))
+	for _, idx := range []int{11, 12, 19, 21} {
+		value, err := strconv.ParseUint(fields[idx], 10, 64)
+		if err != nil {
+			return err
+		}
+		values[idx] = value
+	}
+
+	stat.UserTimeUs = ticksToUs(values[11])
+	stat.SystemTimeUs = ticksToUs(values[12])
+	stat.StartTime = values[19]
+	stat.RSS = values[21] * uint64(os.Getpagesize())
+
+	return nil
+}
+
+func populateProcessKeyed(cg Cgroup, pid int, file string, tagName string, stat *ProcessStat) error {
+	fd, err := fsOpen(cg, procPath(cg, strconv.Itoa(pid), file))
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	rawStats := make(map[string]uint64)
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		rawStats[parts[0]] = value
+	}
+
+	v := reflect.ValueOf(stat).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag
+
+		statName := tag.Get(tagName)
+		if statName == "" {
+			continue
+		}
+
+		if value, found := rawStats[statName]; found {
+			v.Field(i).SetUint(value)
+		}
+	}
+
+	return nil
+}
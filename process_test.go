@@ -0,0 +1,137 @@
+package cgroups
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestProcessStatParse(t *testing.T) {
+	var stat ProcessStat
+
+	contents := "1234 (my (odd) proc) S 1 1234 1234 0 -1 4194560 1000 0 5 0 250 100 0 0 20 0 3 0 98765 123456789 512 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 2 0 0 0 0 0\n"
+
+	err := parseProcessStat(contents, &stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.Comm != "my (odd) proc" {
+		t.Fail()
+	}
+
+	if stat.State != "S" {
+		t.Fail()
+	}
+
+	if stat.UserTimeUs != ticksToUs(250) || stat.SystemTimeUs != ticksToUs(100) {
+		t.Fail()
+	}
+
+	if stat.StartTime != 98765 {
+		t.Fail()
+	}
+
+	if stat.RSS != 512*uint64(os.Getpagesize()) {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stat)
+}
+
+func TestProcessStatSelf(t *testing.T) {
+	stat, err := GetProcessStat(os.Getpid())
+
+	if err != nil {
+		t.Fail()
+	}
+
+	if stat.RSS < 1024 {
+		t.Fail()
+	}
+
+	if stat.Threads < 1 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stat)
+}
+
+func TestProcessDeltaPidReuse(t *testing.T) {
+	now := time.Now()
+
+	prev := ProcessItemizedStats{Stats: make(map[ProcessKey]ProcessStat)}
+	cur := ProcessItemizedStats{Stats: make(map[ProcessKey]ProcessStat)}
+
+	p1 := ProcessStat{Pid: 10, StartTime: 100, UserTimeUs: 0, RChar: 0, SampleTime: now}
+	p2 := ProcessStat{Pid: 11, StartTime: 100, SampleTime: now}
+	prev.Stats[p1.Key()] = p1
+	prev.Stats[p2.Key()] = p2
+
+	p1.UserTimeUs = 500 * 1000
+	p1.RChar = 4096
+	p1.SampleTime = now.Add(time.Second)
+	p2.StartTime = 200
+	p2.SampleTime = now.Add(time.Second)
+	cur.Stats[p1.Key()] = p1
+	cur.Stats[p2.Key()] = p2
+
+	deltas := cur.Delta(prev)
+
+	if len(deltas) != 1 {
+		t.Fail()
+	}
+
+	delta, ok := deltas[ProcessKey{Pid: 10, StartTime: 100}]
+	if !ok {
+		t.FailNow()
+	}
+
+	if delta.UserUsagePct != 50.0 || delta.RCharRate != 4096 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", deltas)
+}
+
+func TestProcessDeltaSameTime(t *testing.T) {
+	now := time.Now()
+
+	prev := ProcessStat{RChar: 1, SampleTime: now}
+	cur := ProcessStat{RChar: 2, SampleTime: now}
+
+	if delta := CalcProcessDeltaStats(cur, prev); delta != (ProcessDeltaStat{}) {
+		t.Errorf("%+v\n", delta)
+	}
+}
+
+func TestProcessStats(t *testing.T) {
+	stats, err := GetProcessStats(fixtureCgroup(fixtures.V2))
+
+	if err != nil {
+		t.Fail()
+	}
+
+	if len(stats.Stats) != 2 {
+		t.Fail()
+	}
+
+	stat := stats.Stats[ProcessKey{Pid: 1234, StartTime: 98765}]
+
+	if stat.Comm != "nginx" || stat.Threads != 2 || stat.VoluntaryCtxSwitches != 150 || stat.ReadBytes != 65536 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stats)
+}
+
+func BenchmarkProcessStats(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, err := GetProcessStats(fixtureCgroup(fixtures.V1))
+		if err != nil {
+			b.Fail()
+		}
+	}
+}
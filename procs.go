@@ -2,22 +2,247 @@ package cgroups
 
 import (
 	"bufio"
-	"os"
+	"errors"
+	"path"
 	"strconv"
 	"strings"
 )
 
-func GetProcs(cg Cgroup) ([]int, error) {
-	pids := make([]int, 0, 16)
+var (
+	ErrAmbiguousCgroup   = errors.New("go-cgroups: More than one cgroup matches")
+	ErrInvalidContainer  = errors.New("go-cgroups: Container ID must be at least 4 hex digits")
+	ErrInvalidCgroupType = errors.New("go-cgroups: Only the threaded cgroup type can be set")
+)
+
+const (
+	CgroupTypeDomain         = "domain"
+	CgroupTypeDomainThreaded = "domain threaded"
+	CgroupTypeDomainInvalid  = "domain invalid"
+	CgroupTypeThreaded       = "threaded"
+)
 
+func GetProcs(cg Cgroup) ([]int, error) {
 	path, err := GetCgroupPath(cg, ControllerCpu, "cgroup.procs")
 	if err == ErrNoCgroup {
-		return pids, err
+		return make([]int, 0), err
+	}
+
+	return readIdList(cg, path)
+}
+
+// GetTasks returns the thread IDs in the cgroup, read from "tasks" on v1
+// and from "cgroup.threads" on v2.
+func GetTasks(cg Cgroup) ([]int, error) {
+	path, err := getTasksPath(cg)
+	if err != nil {
+		return make([]int, 0), err
+	}
+
+	return readIdList(cg, path)
+}
+
+// MoveTask moves a single thread into the cgroup. On v2, the destination
+// has to be a threaded cgroup within the same threaded subtree.
+func MoveTask(cg Cgroup, tid int) error {
+	path, err := getTasksPath(cg)
+	if err != nil {
+		return err
+	}
+
+	return fsWriteFile(cg, path, []byte(strconv.Itoa(tid)))
+}
+
+// GetCgroupType returns the contents of cgroup.type (v2 only), e.g.
+// CgroupTypeDomain or CgroupTypeThreaded.
+func GetCgroupType(cg Cgroup) (string, error) {
+	path, err := GetCgroupPath(cg, ControllerCpu, "cgroup.type")
+	if err == ErrNoCgroup {
+		return "", err
+	}
+
+	contentsRaw, err := fsReadFile(cg, path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(contentsRaw)), nil
+}
+
+// SetCgroupType writes cgroup.type (v2 only). The kernel only accepts
+// CgroupTypeThreaded, anything else fails with ErrInvalidCgroupType; a
+// threaded cgroup cannot be turned back into a domain.
+func SetCgroupType(cg Cgroup, cgType string) error {
+	if cgType != CgroupTypeThreaded {
+		return ErrInvalidCgroupType
+	}
+
+	path, err := GetCgroupPath(cg, ControllerCpu, "cgroup.type")
+	if err == ErrNoCgroup {
+		return err
+	}
+
+	return fsWriteFile(cg, path, []byte(cgType))
+}
+
+// GetPidCgroup returns the cgroup of a process in the hierarchy of the
+// given controller, read from /proc/<pid>/cgroup. On v2 and for
+// controllers not mounted on v1, the unified hierarchy is used.
+func GetPidCgroup(pid int, controller string) (Cgroup, error) {
+	return Host{}.GetPidCgroup(pid, controller)
+}
+
+// readPidCgroup returns the cgroup of a process as a Cgroup with the
+// roots and FS of cg.
+func readPidCgroup(cg Cgroup, pid int, controller string) (Cgroup, error) {
+	fd, err := fsOpen(cg, procPath(cg, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return Cgroup{}, err
+	}
+	defer fd.Close()
+
+	found, err := parsePidCgroup(bufio.NewScanner(fd), controller)
+	if err != nil {
+		return Cgroup{}, err
+	}
+
+	return withCgroup(cg, found.Cgroup), nil
+}
+
+func parsePidCgroup(scanner *bufio.Scanner, controller string) (Cgroup, error) {
+	unified := ""
+
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		if parts[0] == "0" && parts[1] == "" {
+			unified = parts[2]
+			continue
+		}
+
+		for _, c := range strings.Split(parts[1], ",") {
+			if c == controller {
+				return Cgroup{Cgroup: parts[2]}, nil
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Cgroup{}, err
+	}
+
+	if unified == "" {
+		return Cgroup{}, ErrNoCgroup
 	}
 
-	fd, err := os.Open(path)
+	return Cgroup{Cgroup: unified}, nil
+}
+
+const (
+	MinContainerIDPrefix = 4
+
+	containerIDLen = 64
+)
+
+// FindContainerCgroup looks below cg for the cgroup of a container, given
+// a prefix of at least MinContainerIDPrefix hex digits of its ID. Both the
+// cgroupfs layout (/docker/<id>) and the systemd one
+// (<runtime>-<id>.scope) are recognised, where <id> is the full 64 digit
+// ID; other cgroups, such as systemd slices and sessions, never match.
+func FindContainerCgroup(cg Cgroup, id string, controller string) (Cgroup, error) {
+	id = strings.ToLower(id)
+	if len(id) < MinContainerIDPrefix || !isHex(id) {
+		return Cgroup{}, ErrInvalidContainer
+	}
+
+	cgroups, err := ListCgroups(cg, controller)
+	if err != nil {
+		return Cgroup{}, err
+	}
+
+	var found []Cgroup
+
+	for _, candidate := range cgroups {
+		name, ok := containerID(path.Base(candidate.Cgroup))
+		if !ok || !strings.HasPrefix(name, id) {
+			continue
+		}
+
+		// Some runtimes nest the container's processes in a child cgroup
+		// of the same name; keep the outermost one.
+		if len(found) > 0 && strings.HasPrefix(candidate.Cgroup, found[len(found)-1].Cgroup+"/") {
+			continue
+		}
+
+		found = append(found, candidate)
+	}
+
+	switch len(found) {
+	case 0:
+		return Cgroup{}, ErrNoCgroup
+	case 1:
+		return found[0], nil
+	default:
+		return Cgroup{}, ErrAmbiguousCgroup
+	}
+}
+
+// containerID returns the container ID in a cgroup name of the form
+// <id> or <runtime>-<id>.scope. The scopes of the conmon monitor that
+// CRI-O and Podman start next to each container are skipped.
+func containerID(name string) (string, bool) {
+	if strings.HasSuffix(name, ".scope") {
+		name = strings.TrimSuffix(name, ".scope")
+
+		i := strings.LastIndex(name, "-")
+		if i <= 0 || strings.HasSuffix(name[:i], "conmon") {
+			return "", false
+		}
+
+		name = name[i+1:]
+	}
+
+	if len(name) != containerIDLen || !isHex(name) {
+		return "", false
+	}
+
+	return name, true
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func getTasksPath(cg Cgroup) (string, error) {
+	for _, file := range []string{"tasks", "cgroup.threads"} {
+		path, err := GetCgroupPath(cg, ControllerCpu, file)
+		if err == ErrNoCgroup {
+			return "", err
+		}
+
+		if _, err := fsStat(cg, path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", ErrNoStat
+}
+
+func readIdList(cg Cgroup, path string) ([]int, error) {
+	ids := make([]int, 0, 16)
+
+	fd, err := fsOpen(cg, path)
 	if err != nil {
-		return pids, err
+		return ids, err
 	}
 	defer fd.Close()
 
@@ -27,9 +252,9 @@ func GetProcs(cg Cgroup) ([]int, error) {
 		value, err := strconv.ParseInt(str, 10, 32)
 
 		if err == nil {
-			pids = append(pids, int(value))
+			ids = append(ids, int(value))
 		}
 	}
 
-	return pids, nil
+	return ids, nil
 }
@@ -1,27 +1,152 @@
 package cgroups
 
 import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/bwalex/go-cgroups/fixtures"
 )
 
 func TestProcs(t *testing.T) {
-	pids, err := GetProcs(Cgroup{ Cgroup: "/system.slice" })
+	for name, fsys := range fixtures.All {
+		pids, err := GetProcs(fixtureCgroup(fsys))
 
-	if err != nil {
+		if err != nil {
+			t.Fail()
+		}
+
+		if !reflect.DeepEqual(pids, fixtures.Pids) {
+			t.Errorf("%s: %+v\n", name, pids)
+		}
+	}
+}
+
+func TestPidCgroup(t *testing.T) {
+	contents := "4:memory:/docker/abc\n2:cpu,cpuacct:/system.slice/foo.service\n0::/init.scope\n"
+
+	cg, err := parsePidCgroup(bufio.NewScanner(strings.NewReader(contents)), ControllerCpuacct)
+	if err != nil || cg.Cgroup != "/system.slice/foo.service" {
 		t.Fail()
 	}
 
-	if len(pids) < 1 {
+	cg, err = parsePidCgroup(bufio.NewScanner(strings.NewReader(contents)), ControllerPids)
+	if err != nil || cg.Cgroup != "/init.scope" {
+		t.Fail()
+	}
+
+	cg, err = readPidCgroup(fixtureCgroup(fixtures.Hybrid), 1234, ControllerMemory)
+	if err != nil || cg.Cgroup != fixtures.Cgroup {
+		t.Fail()
+	}
+
+	cg, err = readPidCgroup(fixtureCgroup(fixtures.Hybrid), 1234, ControllerPsi)
+	if err != nil || cg.Cgroup != "/system.slice/nginx.service" {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", cg)
+}
+
+func TestFindContainerCgroup(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	id1 := "0123abcd" + strings.Repeat("0", 56)
+	id2 := "0123ef01" + strings.Repeat("1", 56)
+	id3 := "89abcdef" + strings.Repeat("2", 56)
+
+	dirs := []string{
+		"system.slice/docker-" + id1 + ".scope/init",
+		"system.slice/crio-" + id2 + ".scope",
+		"system.slice/crio-conmon-" + id2 + ".scope",
+		"docker/" + id3,
+		// Not containers
+		"init.scope",
+		"user.slice/user-1000.slice/session-2.scope",
+		"system.slice/abcd.service",
+		"system.slice/foo-abcd.scope",
+	}
+
+	for _, dir := range dirs {
+		if err := os.MkdirAll(path.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cg := Cgroup{Root: root, Cgroup: "/"}
+
+	found, err := FindContainerCgroup(cg, "0123ab", ControllerCpu)
+	if err != nil || found.Cgroup != "/system.slice/docker-"+id1+".scope" {
+		t.Errorf("%+v %v\n", found, err)
+	}
+
+	found, err = FindContainerCgroup(cg, "0123EF", ControllerCpu)
+	if err != nil || found.Cgroup != "/system.slice/crio-"+id2+".scope" {
+		t.Errorf("%+v %v\n", found, err)
+	}
+
+	found, err = FindContainerCgroup(cg, id3, ControllerCpu)
+	if err != nil || found.Cgroup != "/docker/"+id3 {
+		t.Errorf("%+v %v\n", found, err)
+	}
+
+	if _, err := FindContainerCgroup(cg, "0123", ControllerCpu); err != ErrAmbiguousCgroup {
 		t.Fail()
 	}
 
-	t.Logf("%+v\n", pids)
+	for _, id := range []string{"abcd", "1000", "ffff"} {
+		if found, err := FindContainerCgroup(cg, id, ControllerCpu); err != ErrNoCgroup {
+			t.Errorf("%s: %+v %v\n", id, found, err)
+		}
+	}
+
+	for _, id := range []string{"", "1", "012", "session", "0123-"} {
+		if _, err := FindContainerCgroup(cg, id, ControllerCpu); err != ErrInvalidContainer {
+			t.Errorf("%s: %v\n", id, err)
+		}
+	}
 }
 
+func TestTasks(t *testing.T) {
+	for name, fsys := range fixtures.All {
+		tids, err := GetTasks(fixtureCgroup(fsys))
+
+		if err != nil {
+			t.Fail()
+		}
+
+		if !reflect.DeepEqual(tids, fixtures.Tids) {
+			t.Errorf("%s: %+v\n", name, tids)
+		}
+	}
+
+	cgType, err := GetCgroupType(fixtureCgroup(fixtures.V2))
+	if err != nil || cgType != CgroupTypeDomain {
+		t.Fail()
+	}
+
+	if err := SetCgroupType(fixtureCgroup(fixtures.V2), CgroupTypeThreaded); err != ErrReadOnlyFS {
+		t.Fail()
+	}
+
+	for _, cgType := range []string{CgroupTypeDomain, CgroupTypeDomainThreaded, "bogus"} {
+		if err := SetCgroupType(fixtureCgroup(fixtures.V2), cgType); err != ErrInvalidCgroupType {
+			t.Errorf("%s: %v\n", cgType, err)
+		}
+	}
+}
 
 func BenchmarkProcs(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := GetProcs(Cgroup{ Cgroup: "/system.slice" })
+		_, err := GetProcs(fixtureCgroup(fixtures.V1))
 		if err != nil {
 			b.Fail()
 		}
@@ -0,0 +1,331 @@
+package promexport
+
+import (
+	"strconv"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+const (
+	counter = "counter"
+	gauge   = "gauge"
+)
+
+var metrics = []metric{
+	// cpu
+	{
+		name: "container_cpu_usage_seconds_total", typ: counter, controller: cgroups.ControllerCpu,
+		help: "Cumulative CPU time consumed in seconds.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			if s.Cpu.UsageNs != 0 {
+				emit(float64(s.Cpu.UsageNs) / 1e9)
+			} else {
+				emit(float64(s.Cpu.UserTimeUs+s.Cpu.SystemTimeUs) / 1e6)
+			}
+		},
+	},
+	{
+		name: "container_cpu_user_seconds_total", typ: counter, controller: cgroups.ControllerCpu,
+		help: "Cumulative user CPU time consumed in seconds.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Cpu.UserTimeUs) / 1e6)
+		},
+	},
+	{
+		name: "container_cpu_system_seconds_total", typ: counter, controller: cgroups.ControllerCpu,
+		help: "Cumulative system CPU time consumed in seconds.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Cpu.SystemTimeUs) / 1e6)
+		},
+	},
+	{
+		name: "container_cpu_per_cpu_usage_seconds_total", typ: counter, controller: cgroups.ControllerCpu,
+		help: "Cumulative CPU time consumed per CPU in seconds.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			for i, ns := range s.Cpu.PerCpuUsageNs {
+				emit(float64(ns)/1e9, "cpu", strconv.Itoa(i))
+			}
+		},
+	},
+	{
+		name: "container_cpu_cfs_periods_total", typ: counter, controller: cgroups.ControllerCpu,
+		help: "Number of elapsed enforcement period intervals.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Cpu.Periods))
+		},
+	},
+	{
+		name: "container_cpu_cfs_throttled_periods_total", typ: counter, controller: cgroups.ControllerCpu,
+		help: "Number of throttled period intervals.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Cpu.ThrottledPeriods))
+		},
+	},
+	{
+		name: "container_cpu_cfs_throttled_seconds_total", typ: counter, controller: cgroups.ControllerCpu,
+		help: "Total time duration the container has been throttled.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Cpu.ThrottledTimeUs) / 1e6)
+		},
+	},
+	{
+		name: "container_spec_cpu_quota", typ: gauge, controller: cgroups.ControllerCpu,
+		help: "CPU quota of the container in microseconds per period.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			if s.Cpu.QuotaUs != 0 {
+				emit(float64(s.Cpu.QuotaUs))
+			}
+		},
+	},
+	{
+		name: "container_spec_cpu_period", typ: gauge, controller: cgroups.ControllerCpu,
+		help: "CPU period of the container in microseconds.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			if s.Cpu.PeriodUs != 0 {
+				emit(float64(s.Cpu.PeriodUs))
+			}
+		},
+	},
+
+	// memory
+	{
+		name: "container_memory_usage_bytes", typ: gauge, controller: cgroups.ControllerMemory,
+		help: "Current memory usage in bytes, including all memory regardless of when it was accessed.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Memory.MemUsage))
+		},
+	},
+	{
+		name: "container_memory_working_set_bytes", typ: gauge, controller: cgroups.ControllerMemory,
+		help: "Current working set in bytes.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Memory.WorkingSet()))
+		},
+	},
+	{
+		name: "container_memory_rss", typ: gauge, controller: cgroups.ControllerMemory,
+		help: "Size of RSS in bytes.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(hier(s.Memory.TotalRSS, s.Memory.RSS)))
+		},
+	},
+	{
+		name: "container_memory_cache", typ: gauge, controller: cgroups.ControllerMemory,
+		help: "Number of bytes of page cache memory.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(hier(s.Memory.TotalCache, s.Memory.Cache)))
+		},
+	},
+	{
+		name: "container_memory_mapped_file", typ: gauge, controller: cgroups.ControllerMemory,
+		help: "Size of memory mapped files in bytes.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(hier(s.Memory.TotalMappedFile, s.Memory.MappedFile)))
+		},
+	},
+	{
+		name: "container_memory_swap", typ: gauge, controller: cgroups.ControllerMemory,
+		help: "Container swap usage in bytes.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			if s.Memory.SwapUsage != 0 {
+				emit(float64(s.Memory.SwapUsage))
+			} else {
+				emit(float64(hier(s.Memory.TotalSwap, s.Memory.Swap)))
+			}
+		},
+	},
+	{
+		name: "container_memory_kernel_bytes", typ: gauge, controller: cgroups.ControllerMemory,
+		help: "Kernel memory usage in bytes.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			if s.Memory.Kernel != 0 {
+				emit(float64(s.Memory.Kernel))
+			} else {
+				emit(float64(s.Memory.KMemUsage))
+			}
+		},
+	},
+	{
+		name: "container_memory_failcnt_total", typ: counter, controller: cgroups.ControllerMemory,
+		help: "Number of memory usage hits limits.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Memory.MemFailCnt))
+		},
+	},
+	{
+		name: "container_memory_failures_total", typ: counter, controller: cgroups.ControllerMemory,
+		help: "Cumulative count of memory allocation failures.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(hier(s.Memory.TotalPgFault, s.Memory.PgFault)), "failure_type", "pgfault")
+			emit(float64(hier(s.Memory.TotalPgMajFault, s.Memory.PgMajFault)), "failure_type", "pgmajfault")
+		},
+	},
+	{
+		name: "container_spec_memory_limit_bytes", typ: gauge, controller: cgroups.ControllerMemory,
+		help: "Memory limit for the container, omitted if unlimited.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			if s.Memory.HasLimit() {
+				emit(float64(s.Memory.MemLimit))
+			}
+		},
+	},
+
+	// blkio
+	{
+		name: "container_blkio_device_usage_total", typ: counter, controller: cgroups.ControllerBlkio,
+		help: "Blkio device bytes usage.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			for _, dev := range blkioDevices(s.BlkioDevices.Stats) {
+				stat := s.BlkioDevices.Stats[dev]
+				emit(float64(stat.ServiceBytesRead), "device", dev, "op", "Read")
+				emit(float64(stat.ServiceBytesWrite), "device", dev, "op", "Write")
+			}
+		},
+	},
+	{
+		name: "container_blkio_device_serviced_total", typ: counter, controller: cgroups.ControllerBlkio,
+		help: "Blkio device I/O operations completed.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			for _, dev := range blkioDevices(s.BlkioDevices.Stats) {
+				stat := s.BlkioDevices.Stats[dev]
+				emit(float64(stat.ServicedRead), "device", dev, "op", "Read")
+				emit(float64(stat.ServicedWrite), "device", dev, "op", "Write")
+			}
+		},
+	},
+
+	// net
+	{
+		name: "container_network_receive_bytes_total", typ: counter, controller: cgroups.ControllerNet,
+		help: "Cumulative count of bytes received.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emitNet(s, emit, func(n cgroups.NetStat) uint64 { return n.RxBytes })
+		},
+	},
+	{
+		name: "container_network_receive_packets_total", typ: counter, controller: cgroups.ControllerNet,
+		help: "Cumulative count of packets received.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emitNet(s, emit, func(n cgroups.NetStat) uint64 { return n.RxPackets })
+		},
+	},
+	{
+		name: "container_network_receive_errors_total", typ: counter, controller: cgroups.ControllerNet,
+		help: "Cumulative count of errors encountered while receiving.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emitNet(s, emit, func(n cgroups.NetStat) uint64 { return n.RxErrors })
+		},
+	},
+	{
+		name: "container_network_receive_packets_dropped_total", typ: counter, controller: cgroups.ControllerNet,
+		help: "Cumulative count of packets dropped while receiving.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emitNet(s, emit, func(n cgroups.NetStat) uint64 { return n.RxDrop })
+		},
+	},
+	{
+		name: "container_network_transmit_bytes_total", typ: counter, controller: cgroups.ControllerNet,
+		help: "Cumulative count of bytes transmitted.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emitNet(s, emit, func(n cgroups.NetStat) uint64 { return n.TxBytes })
+		},
+	},
+	{
+		name: "container_network_transmit_packets_total", typ: counter, controller: cgroups.ControllerNet,
+		help: "Cumulative count of packets transmitted.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emitNet(s, emit, func(n cgroups.NetStat) uint64 { return n.TxPackets })
+		},
+	},
+	{
+		name: "container_network_transmit_errors_total", typ: counter, controller: cgroups.ControllerNet,
+		help: "Cumulative count of errors encountered while transmitting.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emitNet(s, emit, func(n cgroups.NetStat) uint64 { return n.TxErrors })
+		},
+	},
+	{
+		name: "container_network_transmit_packets_dropped_total", typ: counter, controller: cgroups.ControllerNet,
+		help: "Cumulative count of packets dropped while transmitting.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emitNet(s, emit, func(n cgroups.NetStat) uint64 { return n.TxDrop })
+		},
+	},
+
+	// pids
+	{
+		name: "container_processes", typ: gauge, controller: cgroups.ControllerPids,
+		help: "Number of processes running inside the container.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Pids.Current))
+		},
+	},
+	{
+		name: "container_spec_pids_limit", typ: gauge, controller: cgroups.ControllerPids,
+		help: "Maximum number of processes, omitted if unlimited.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			if s.Pids.Limit != 0 {
+				emit(float64(s.Pids.Limit))
+			}
+		},
+	},
+
+	// psi
+	{
+		name: "container_pressure_cpu_waiting_seconds_total", typ: counter, controller: cgroups.ControllerPsi,
+		help: "Total time duration tasks in the container have waited due to CPU congestion.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Psi.Cpu.Some.TotalUs) / 1e6)
+		},
+	},
+	{
+		name: "container_pressure_cpu_stalled_seconds_total", typ: counter, controller: cgroups.ControllerPsi,
+		help: "Total time duration no tasks in the container could make progress due to CPU congestion.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Psi.Cpu.Full.TotalUs) / 1e6)
+		},
+	},
+	{
+		name: "container_pressure_memory_waiting_seconds_total", typ: counter, controller: cgroups.ControllerPsi,
+		help: "Total time duration tasks in the container have waited due to memory congestion.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Psi.Memory.Some.TotalUs) / 1e6)
+		},
+	},
+	{
+		name: "container_pressure_memory_stalled_seconds_total", typ: counter, controller: cgroups.ControllerPsi,
+		help: "Total time duration no tasks in the container could make progress due to memory congestion.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Psi.Memory.Full.TotalUs) / 1e6)
+		},
+	},
+	{
+		name: "container_pressure_io_waiting_seconds_total", typ: counter, controller: cgroups.ControllerPsi,
+		help: "Total time duration tasks in the container have waited due to IO congestion.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Psi.Io.Some.TotalUs) / 1e6)
+		},
+	},
+	{
+		name: "container_pressure_io_stalled_seconds_total", typ: counter, controller: cgroups.ControllerPsi,
+		help: "Total time duration no tasks in the container could make progress due to IO congestion.",
+		collect: func(s *cgroups.CgroupStats, emit func(float64, ...string)) {
+			emit(float64(s.Psi.Io.Full.TotalUs) / 1e6)
+		},
+	},
+}
+
+// hier prefers the hierarchical v1 counter where there is one.
+func hier(total uint64, local uint64) uint64 {
+	if total != 0 {
+		return total
+	}
+
+	return local
+}
+
+func emitNet(s *cgroups.CgroupStats, emit func(float64, ...string), value func(cgroups.NetStat) uint64) {
+	for _, intf := range netInterfaces(s.Net.Stats) {
+		emit(float64(value(s.Net.Stats[intf])), "interface", intf)
+	}
+}
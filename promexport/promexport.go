@@ -0,0 +1,157 @@
+// Package promexport writes cgroup stats in the Prometheus text
+// exposition format. It has no dependency on the Prometheus client
+// library and does not listen on the network; an Exporter is an
+// http.Handler that can be mounted on any server.
+package promexport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	cgroups "github.com/bwalex/go-cgroups"
+)
+
+const (
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+type Exporter struct {
+	Cgroups []cgroups.Cgroup
+	Options cgroups.StatsOptions
+}
+
+func NewExporter(cgs []cgroups.Cgroup) *Exporter {
+	return &Exporter{
+		Cgroups: cgs,
+		Options: cgroups.StatsOptions{BlkioDevices: true},
+	}
+}
+
+// Collect reads the current stats of every cgroup.
+func (e *Exporter) Collect() []cgroups.CgroupStats {
+	stats := make([]cgroups.CgroupStats, 0, len(e.Cgroups))
+
+	for _, cg := range e.Cgroups {
+		stats = append(stats, cgroups.GetAllStats(cg, e.Options))
+	}
+
+	return stats
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Render first, so that an error can still change the status
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf, e.Collect()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(buf.Bytes())
+}
+
+type sample struct {
+	labels []string /* name/value pairs */
+	value  float64
+}
+
+type metric struct {
+	name       string
+	help       string
+	typ        string
+	controller string
+	collect    func(stats *cgroups.CgroupStats, emit func(value float64, labels ...string))
+}
+
+// WriteMetrics writes the given stats in the text exposition format.
+// Counters are written as raw cumulative values. Each sample carries a
+// "cgroup" label; metrics of controllers that were skipped or could not
+// be read for a cgroup are omitted for it.
+func WriteMetrics(w io.Writer, stats []cgroups.CgroupStats) error {
+	bw := bufio.NewWriter(w)
+
+	for _, m := range metrics {
+		samples := make([]sample, 0, len(stats))
+
+		for i := range stats {
+			if !stats[i].Collected(m.controller) {
+				continue
+			}
+
+			cgLabel := stats[i].Cgroup.Cgroup
+
+			m.collect(&stats[i], func(value float64, labels ...string) {
+				samples = append(samples, sample{
+					labels: append([]string{"cgroup", cgLabel}, labels...),
+					value:  value,
+				})
+			})
+		}
+
+		if len(samples) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", m.name, m.typ)
+
+		for _, s := range samples {
+			bw.WriteString(m.name)
+			writeLabels(bw, s.labels)
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+			bw.WriteByte('\n')
+		}
+	}
+
+	return bw.Flush()
+}
+
+func writeLabels(bw *bufio.Writer, labels []string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	bw.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+
+		bw.WriteString(labels[i])
+		bw.WriteString(`="`)
+		bw.WriteString(labelEscaper.Replace(labels[i+1]))
+		bw.WriteByte('"')
+	}
+	bw.WriteByte('}')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func netInterfaces(stats map[string]cgroups.NetStat) []string {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func blkioDevices(stats map[string]cgroups.BlkioStat) []string {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
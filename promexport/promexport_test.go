@@ -0,0 +1,83 @@
+package promexport
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cgroups "github.com/bwalex/go-cgroups"
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	stats := []cgroups.CgroupStats{
+		{
+			Cgroup: cgroups.Cgroup{Cgroup: `/system.slice/"odd".service`},
+			Cpu:    cgroups.CpuStat{UsageNs: 1500000000},
+			Memory: cgroups.MemoryStat{MemUsage: 1000, TotalInactiveFile: 400, MemLimit: cgroups.MemoryUnlimited},
+			Net: cgroups.NetItemizedStats{Stats: map[string]cgroups.NetStat{
+				"eth0": {RxBytes: 42},
+			}},
+			BlkioDevices: cgroups.BlkioItemizedStats{Stats: map[string]cgroups.BlkioStat{
+				"sda": {ServiceBytesRead: 4096, ServiceBytesWrite: 512},
+			}},
+			Errors:  map[string]error{cgroups.ControllerPids: cgroups.ErrNoCgroup},
+			Options: cgroups.StatsOptions{SkipPsi: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf, stats); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE container_cpu_usage_seconds_total counter\n",
+		`container_cpu_usage_seconds_total{cgroup="/system.slice/\"odd\".service"} 1.5` + "\n",
+		`container_memory_working_set_bytes{cgroup="/system.slice/\"odd\".service"} 600` + "\n",
+		`container_network_receive_bytes_total{cgroup="/system.slice/\"odd\".service",interface="eth0"} 42` + "\n",
+		`container_blkio_device_usage_total{cgroup="/system.slice/\"odd\".service",device="sda",op="Read"} 4096` + "\n",
+		"# TYPE container_memory_failcnt_total counter\n",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing %q", line)
+		}
+	}
+
+	unexpected := []string{
+		"container_processes",
+		"container_pressure_",
+		"container_spec_memory_limit_bytes{",
+	}
+
+	for _, line := range unexpected {
+		if strings.Contains(out, line) {
+			t.Errorf("unexpected %q", line)
+		}
+	}
+
+	t.Log(out)
+}
+
+func TestServeHTTP(t *testing.T) {
+	exporter := NewExporter([]cgroups.Cgroup{{FS: fixtures.V2, Cgroup: fixtures.Cgroup}})
+	exporter.Options.SkipNet = true
+
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	out := rec.Body.String()
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentType {
+		t.Errorf("%d %v\n", rec.Code, rec.Header())
+	}
+
+	if !strings.Contains(out, "container_pressure_io_stalled_seconds_total") || strings.Contains(out, "container_network_") {
+		t.Error(out)
+	}
+}
@@ -0,0 +1,105 @@
+package cgroups
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PressureLine struct {
+	Avg10   float64 `json:"avg10_pct,omitempty"`  /* in percent */
+	Avg60   float64 `json:"avg60_pct,omitempty"`  /* in percent */
+	Avg300  float64 `json:"avg300_pct,omitempty"` /* in percent */
+	TotalUs uint64  `json:"total_us,omitempty"`   /* in microseconds */
+}
+
+// Pressure stall information for one resource. Full is not reported for
+// CPU by older kernels.
+type PressureStat struct {
+	Some PressureLine `json:"some"`
+	Full PressureLine `json:"full"`
+}
+
+// From cpu.pressure, memory.pressure and io.pressure (v2 only, found in
+// the unified hierarchy on hybrid systems).
+type PsiStat struct {
+	Cpu    PressureStat `json:"cpu"`
+	Memory PressureStat `json:"memory"`
+	Io     PressureStat `json:"io"`
+
+	SampleTime time.Time `json:"-"`
+}
+
+const (
+	ControllerUnified = "unified"
+)
+
+func GetPsiStats(cg Cgroup) (PsiStat, error) {
+	var stats PsiStat
+
+	stats.SampleTime = time.Now()
+
+	files := map[string]*PressureStat{
+		"cpu.pressure":    &stats.Cpu,
+		"memory.pressure": &stats.Memory,
+		"io.pressure":     &stats.Io,
+	}
+
+	found := false
+
+	for file, stat := range files {
+		contents, err := readCgroupFile(cg, ControllerUnified, file)
+		if err == ErrNoCgroup {
+			return stats, err
+		} else if err != nil {
+			continue
+		}
+
+		parsePressure(contents, stat)
+		found = true
+	}
+
+	if !found {
+		return stats, ErrNoStat
+	}
+
+	return stats, nil
+}
+
+func parsePressure(contents string, stat *PressureStat) {
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 1 {
+			continue
+		}
+
+		var pl *PressureLine
+
+		switch fields[0] {
+		case "some":
+			pl = &stat.Some
+		case "full":
+			pl = &stat.Full
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+
+			switch parts[0] {
+			case "avg10":
+				pl.Avg10, _ = strconv.ParseFloat(parts[1], 64)
+			case "avg60":
+				pl.Avg60, _ = strconv.ParseFloat(parts[1], 64)
+			case "avg300":
+				pl.Avg300, _ = strconv.ParseFloat(parts[1], 64)
+			case "total":
+				pl.TotalUs, _ = strconv.ParseUint(parts[1], 10, 64)
+			}
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package cgroups
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recordings are append-only files with one CgroupStats per line, in the
+// JSON schema described in json.go. Each call to Recorder.Record writes
+// one snapshot of every cgroup; Player.NextBatch reads them back in the
+// same groups.
+
+type Recorder struct {
+	Cgroups []Cgroup
+	Options StatsOptions
+
+	mu sync.Mutex
+	w  *bufio.Writer
+	f  *os.File
+}
+
+type Player struct {
+	scanner *bufio.Scanner
+	f       *os.File
+
+	pending *CgroupStats
+	prev    map[string]CgroupStats
+}
+
+func NewRecorder(w io.Writer, cgroups []Cgroup) *Recorder {
+	return &Recorder{
+		Cgroups: append([]Cgroup(nil), cgroups...),
+		w:       bufio.NewWriter(w),
+	}
+}
+
+// OpenRecorder opens (or creates) a recording file for appending. If an
+// interrupted recorder left a partial last line, the new snapshots start
+// on a line of their own.
+func OpenRecorder(name string, cgroups []Cgroup) (*Recorder, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = endLine(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r := NewRecorder(f, cgroups)
+	r.f = f
+
+	return r, nil
+}
+
+// endLine terminates a non-empty file that does not end in a newline.
+func endLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+
+	if last[0] != '\n' {
+		_, err = f.Write([]byte{'\n'})
+	}
+
+	return err
+}
+
+// Record takes a snapshot of every cgroup with GetAllStats and appends
+// them to the recording.
+func (r *Recorder) Record() error {
+	batch := make([]CgroupStats, 0, len(r.Cgroups))
+
+	for _, cg := range r.Cgroups {
+		batch = append(batch, GetAllStats(cg, r.Options))
+	}
+
+	return r.Write(batch...)
+}
+
+// Write appends snapshots taken elsewhere, e.g. by a Sampler, and
+// flushes them to the underlying writer.
+func (r *Recorder) Write(stats ...CgroupStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range stats {
+		data, err := json.Marshal(stats[i])
+		if err != nil {
+			return err
+		}
+
+		r.w.Write(data)
+		r.w.WriteByte('\n')
+	}
+
+	return r.w.Flush()
+}
+
+// Close flushes the recording, and closes the file if it was opened with
+// OpenRecorder.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.w.Flush()
+
+	if r.f != nil {
+		if closeErr := r.f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+func NewPlayer(r io.Reader) *Player {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	return &Player{
+		scanner: scanner,
+		prev:    make(map[string]CgroupStats),
+	}
+}
+
+func OpenPlayer(name string) (*Player, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewPlayer(f)
+	p.f = f
+
+	return p, nil
+}
+
+func (p *Player) Close() error {
+	if p.f == nil {
+		return nil
+	}
+
+	return p.f.Close()
+}
+
+// Next returns the next snapshot in the recording, or io.EOF at its end.
+// Partially written lines, as left by an interrupted recorder, are
+// skipped.
+func (p *Player) Next() (CgroupStats, error) {
+	if p.pending != nil {
+		stats := *p.pending
+		p.pending = nil
+
+		return stats, nil
+	}
+
+	for p.scanner.Scan() {
+		line := p.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var stats CgroupStats
+		if err := json.Unmarshal(line, &stats); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				continue
+			}
+
+			return CgroupStats{}, err
+		}
+
+		return stats, nil
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return CgroupStats{}, err
+	}
+
+	return CgroupStats{}, io.EOF
+}
+
+// NextBatch returns the snapshots written by one Recorder.Record call: a
+// batch ends just before a cgroup that is already part of it.
+func (p *Player) NextBatch() ([]CgroupStats, error) {
+	batch := make([]CgroupStats, 0)
+	seen := make(map[string]bool)
+
+	for {
+		stats, err := p.Next()
+		if err == io.EOF && len(batch) > 0 {
+			return batch, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[stats.Cgroup.Cgroup] {
+			p.pending = &stats
+			return batch, nil
+		}
+
+		seen[stats.Cgroup.Cgroup] = true
+		batch = append(batch, stats)
+	}
+}
+
+// NextDelta returns the delta between the next snapshot in the recording
+// and the previous one of the same cgroup, exactly as CalcCgroupDeltaStats
+// would have computed it live. The first snapshot of every cgroup only
+// serves as a baseline.
+func (p *Player) NextDelta() (CgroupDeltaStats, error) {
+	for {
+		stats, err := p.Next()
+		if err != nil {
+			return CgroupDeltaStats{}, err
+		}
+
+		prev, ok := p.prev[stats.Cgroup.Cgroup]
+		p.prev[stats.Cgroup.Cgroup] = stats
+
+		if ok {
+			return stats.Delta(prev), nil
+		}
+	}
+}
+
+// ExportCSV writes every remaining snapshot in the recording as one CSV
+// row. The columns are the JSON keys of each controller's stats, prefixed
+// with the controller name; net has the totals over all interfaces but
+// lo.
+func (p *Player) ExportCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"time", "cgroup"}
+	for _, col := range csvColumns {
+		header = append(header, col.name)
+	}
+	cw.Write(header)
+
+	for {
+		stats, err := p.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		record := []string{stats.SampleTime.UTC().Format(time.RFC3339Nano), stats.Cgroup.Cgroup}
+
+		stats.shareSampleTime()
+		controllers := map[string]reflect.Value{
+			ControllerCpu:    reflect.ValueOf(stats.Cpu),
+			ControllerMemory: reflect.ValueOf(stats.Memory),
+			ControllerBlkio:  reflect.ValueOf(stats.Blkio),
+			ControllerNet:    reflect.ValueOf(stats.Net.Total()),
+			ControllerPids:   reflect.ValueOf(stats.Pids),
+			ControllerPsi:    reflect.ValueOf(stats.Psi),
+		}
+
+		for _, col := range csvColumns {
+			if !stats.Collected(col.controller) {
+				record = append(record, "")
+				continue
+			}
+
+			v := controllers[col.controller].FieldByIndex(col.index)
+
+			switch v.Kind() {
+			case reflect.Float64:
+				record = append(record, strconv.FormatFloat(v.Float(), 'f', -1, 64))
+			default:
+				record = append(record, strconv.FormatUint(v.Uint(), 10))
+			}
+		}
+
+		cw.Write(record)
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+type csvColumn struct {
+	controller string
+	name       string
+	index      []int
+}
+
+var csvColumns = buildCsvColumns()
+
+func buildCsvColumns() []csvColumn {
+	columns := make([]csvColumn, 0)
+
+	for _, c := range []struct {
+		controller string
+		v          interface{}
+	}{
+		{ControllerCpu, CpuStat{}},
+		{ControllerMemory, MemoryStat{}},
+		{ControllerBlkio, BlkioStat{}},
+		{ControllerNet, NetStat{}},
+		{ControllerPids, PidsStat{}},
+		{ControllerPsi, PsiStat{}},
+	} {
+		columns = appendCsvColumns(columns, c.controller, c.controller, nil, reflect.TypeOf(c.v))
+	}
+
+	return columns
+}
+
+func appendCsvColumns(columns []csvColumn, controller string, prefix string, index []int, t reflect.Type) []csvColumn {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			columns = appendCsvColumns(columns, controller, prefix+"."+name, fieldIndex, field.Type)
+		case reflect.Uint64, reflect.Float64:
+			columns = append(columns, csvColumn{controller, prefix + "." + name, fieldIndex})
+		}
+	}
+
+	return columns
+}
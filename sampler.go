@@ -0,0 +1,239 @@
+package cgroups
+
+import (
+	"io/fs"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSamplerHistory  = 60
+	DefaultSamplerInterval = time.Second
+)
+
+// Sampler periodically samples a set of cgroups with GetAllStats in a
+// background goroutine, keeps the last History raw samples of each, and
+// publishes the delta between consecutive samples through a callback
+// and/or the Deltas channel.
+type Sampler struct {
+	Interval time.Duration
+	History  int
+	Options  StatsOptions
+
+	cgroups  []Cgroup
+	callback func(CgroupDeltaStats)
+
+	mu      sync.Mutex
+	samples []*sampleRing /* by index into cgroups */
+
+	deltas    chan CgroupDeltaStats
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+type sampleRing struct {
+	samples []CgroupStats
+	next    int
+	count   int
+}
+
+func (r *sampleRing) push(s CgroupStats) {
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+
+	if r.count < len(r.samples) {
+		r.count++
+	}
+}
+
+func (r *sampleRing) last() (CgroupStats, bool) {
+	if r.count == 0 {
+		return CgroupStats{}, false
+	}
+
+	return r.samples[(r.next+len(r.samples)-1)%len(r.samples)], true
+}
+
+func (r *sampleRing) list() []CgroupStats {
+	list := make([]CgroupStats, 0, r.count)
+
+	start := (r.next + len(r.samples) - r.count) % len(r.samples)
+	for i := 0; i < r.count; i++ {
+		list = append(list, r.samples[(start+i)%len(r.samples)])
+	}
+
+	return list
+}
+
+// sameCgroup reports whether a and b refer to the same cgroup in the same
+// filesystems. FSes are compared by identity, as they may not be
+// comparable (e.g. fstest.MapFS).
+func sameCgroup(a Cgroup, b Cgroup) bool {
+	if cgroupRoot(a) != cgroupRoot(b) || a.Cgroup != b.Cgroup || procPath(a) != procPath(b) || sysPath(a) != sysPath(b) {
+		return false
+	}
+
+	return sameFS(a.FS, b.FS)
+}
+
+func sameFS(a fs.FS, b fs.FS) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+
+	switch va.Kind() {
+	case reflect.Map, reflect.Ptr, reflect.Func, reflect.Chan, reflect.Slice:
+		return va.Pointer() == vb.Pointer()
+	}
+
+	if va.Type().Comparable() {
+		return a == b
+	}
+
+	return false
+}
+
+// NewSampler creates a sampler for the given cgroups. A non-positive
+// interval is replaced by DefaultSamplerInterval.
+func NewSampler(cgroups []Cgroup, interval time.Duration, history int) *Sampler {
+	if history < 2 {
+		history = 2
+	}
+
+	if interval <= 0 {
+		interval = DefaultSamplerInterval
+	}
+
+	s := &Sampler{
+		Interval: interval,
+		History:  history,
+		cgroups:  append([]Cgroup(nil), cgroups...),
+		samples:  make([]*sampleRing, len(cgroups)),
+		deltas:   make(chan CgroupDeltaStats, len(cgroups)),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for i := range s.samples {
+		s.samples[i] = &sampleRing{samples: make([]CgroupStats, history)}
+	}
+
+	return s
+}
+
+// SetCallback registers a function that is called, from the sampling
+// goroutine, with every new delta. It must be set before Start.
+func (s *Sampler) SetCallback(callback func(CgroupDeltaStats)) {
+	s.callback = callback
+}
+
+// Deltas returns a channel on which every new delta is published. Deltas
+// are dropped rather than delaying sampling if the channel is full. The
+// channel is closed once the sampler has stopped.
+func (s *Sampler) Deltas() <-chan CgroupDeltaStats {
+	return s.deltas
+}
+
+// Start starts sampling. Calling it more than once, or after Stop, has no
+// effect.
+func (s *Sampler) Start() {
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+}
+
+// Stop stops sampling and waits for the sampling goroutine to exit. No
+// callbacks are made after Stop returns. It is safe to call more than
+// once, and without having called Start.
+func (s *Sampler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	// If the sampler was never started, it can't be any more.
+	s.startOnce.Do(func() {
+		close(s.deltas)
+		close(s.done)
+	})
+
+	<-s.done
+}
+
+// Samples returns the raw samples kept for the cgroup, oldest first.
+func (s *Sampler) Samples(cg Cgroup) []CgroupStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.cgroups {
+		if sameCgroup(s.cgroups[i], cg) {
+			return s.samples[i].list()
+		}
+	}
+
+	return nil
+}
+
+func (s *Sampler) run() {
+	defer close(s.done)
+	defer close(s.deltas)
+
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultSamplerInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.sampleAll()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.sampleAll()
+		}
+	}
+}
+
+func (s *Sampler) sampleAll() {
+	for i, cg := range s.cgroups {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		sample := GetAllStats(cg, s.Options)
+
+		s.mu.Lock()
+		ring := s.samples[i]
+		prev, hasPrev := ring.last()
+		ring.push(sample)
+		s.mu.Unlock()
+
+		if !hasPrev {
+			continue
+		}
+
+		delta := sample.Delta(prev)
+
+		if s.callback != nil {
+			s.callback(delta)
+		}
+
+		select {
+		case s.deltas <- delta:
+		default:
+		}
+	}
+}
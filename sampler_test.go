@@ -0,0 +1,140 @@
+package cgroups
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestSampleRing(t *testing.T) {
+	ring := &sampleRing{samples: make([]CgroupStats, 3)}
+
+	if _, ok := ring.last(); ok {
+		t.Fail()
+	}
+
+	for i := 1; i <= 5; i++ {
+		ring.push(CgroupStats{SampleTime: time.Unix(int64(i), 0)})
+	}
+
+	list := ring.list()
+
+	if len(list) != 3 || list[0].SampleTime.Unix() != 3 || list[2].SampleTime.Unix() != 5 {
+		t.Fail()
+	}
+
+	if last, ok := ring.last(); !ok || last.SampleTime.Unix() != 5 {
+		t.Fail()
+	}
+}
+
+func TestSampler(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"cpu.stat":     "nr_periods 0\nnr_throttled 0\nthrottled_time 0\n",
+		"cpuacct.stat": "user 100\nsystem 50\n",
+	}
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(path.Join(root, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cg := Cgroup{Root: root, Cgroup: "/"}
+
+	sampler := NewSampler([]Cgroup{cg}, 10*time.Millisecond, 4)
+
+	callbacks := 0
+	sampler.SetCallback(func(delta CgroupDeltaStats) {
+		callbacks++
+	})
+
+	sampler.Start()
+
+	for i := 0; i < 2; i++ {
+		delta := <-sampler.Deltas()
+
+		if delta.Cgroup != cg || delta.Interval <= 0 {
+			t.Fail()
+		}
+
+		if _, ok := delta.Errors[ControllerCpu]; ok {
+			t.Fail()
+		}
+
+		if _, ok := delta.Errors[ControllerMemory]; !ok {
+			t.Fail()
+		}
+	}
+
+	sampler.Stop()
+	sampler.Stop()
+
+	for range sampler.Deltas() {
+	}
+
+	if callbacks < 2 {
+		t.Fail()
+	}
+
+	samples := sampler.Samples(cg)
+	if len(samples) < 3 || len(samples) > 4 {
+		t.Fail()
+	}
+}
+
+func TestSamplerStartStop(t *testing.T) {
+	sampler := NewSampler(nil, 0, 0)
+
+	if sampler.Interval != DefaultSamplerInterval {
+		t.Fail()
+	}
+
+	// Never started
+	sampler.Stop()
+	sampler.Start()
+	sampler.Stop()
+
+	if _, ok := <-sampler.Deltas(); ok {
+		t.Fail()
+	}
+
+	sampler = NewSampler(nil, time.Millisecond, 0)
+	sampler.Start()
+	sampler.Start()
+	sampler.Stop()
+}
+
+func TestSamplerSameCgroupPath(t *testing.T) {
+	v1 := fixtureCgroup(fixtures.V1)
+	v2 := fixtureCgroup(fixtures.V2)
+
+	sampler := NewSampler([]Cgroup{v1, v2}, 5*time.Millisecond, 4)
+	sampler.Start()
+
+	for i := 0; i < 2; i++ {
+		<-sampler.Deltas()
+	}
+	sampler.Stop()
+
+	for _, cg := range []Cgroup{v1, v2} {
+		samples := sampler.Samples(cg)
+		if len(samples) < 2 || !sameFS(samples[0].Cgroup.FS, cg.FS) || !sameFS(samples[len(samples)-1].Cgroup.FS, cg.FS) {
+			t.Errorf("%+v\n", samples)
+		}
+	}
+
+	if sampler.Samples(Cgroup{Cgroup: fixtures.Cgroup}) != nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,169 @@
+package cgroups
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CpuSchedStat struct {
+	RunTimeNs  uint64 /* in nanoseconds */
+	WaitTimeNs uint64 /* in nanoseconds, time spent runnable on a run-queue */
+	Timeslices uint64 /* 0 if only cgroup-level data is available */
+	Tasks      uint64
+
+	// Set if the values come from cgroup-level accounting (wait_sum in
+	// cpu.stat) instead of summing the schedstat of each task.
+	CgroupLevel bool
+
+	SampleTime time.Time
+}
+
+type CpuSchedDeltaStat struct {
+	AvgWaitPerTimesliceNs uint64
+	WaitUsPerSec          float64
+	RunUsPerSec           float64
+}
+
+func (stats CpuSchedStat) Delta(prevStats CpuSchedStat) CpuSchedDeltaStat {
+	return CalcCpuSchedDeltaStats(stats, prevStats)
+}
+
+func CalcCpuSchedDeltaStats(stats CpuSchedStat, prevStats CpuSchedStat) CpuSchedDeltaStat {
+	var deltaStat CpuSchedDeltaStat
+
+	// Per-task sums can shrink when tasks exit, so clamp at zero.
+	runTimeDeltaNs := counterDelta(stats.RunTimeNs, prevStats.RunTimeNs)
+	waitTimeDeltaNs := counterDelta(stats.WaitTimeNs, prevStats.WaitTimeNs)
+	timeslicesDelta := counterDelta(stats.Timeslices, prevStats.Timeslices)
+	timeDeltaUs := stats.SampleTime.Sub(prevStats.SampleTime).Nanoseconds() / int64(time.Microsecond)
+
+	deltaStat.RunUsPerSec = float64(runTimeDeltaNs) * 1000 / float64(timeDeltaUs)
+	deltaStat.WaitUsPerSec = float64(waitTimeDeltaNs) * 1000 / float64(timeDeltaUs)
+
+	if timeslicesDelta != 0 {
+		deltaStat.AvgWaitPerTimesliceNs = waitTimeDeltaNs / timeslicesDelta
+	}
+
+	return deltaStat
+}
+
+func GetCpuSchedLatency(cg Cgroup) (CpuSchedStat, error) {
+	var stats CpuSchedStat
+
+	stats.SampleTime = time.Now()
+
+	found, err := populateCpuSchedCgroup(cg, &stats)
+	if err != nil || found {
+		return stats, err
+	}
+
+	err = populateCpuSchedTasks(cg, &stats)
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// populateCpuSchedCgroup uses wait_sum from cpu.stat, which v1 kernels
+// expose when schedstats are enabled, along with cpuacct.usage.
+func populateCpuSchedCgroup(cg Cgroup, stat *CpuSchedStat) (bool, error) {
+	path, err := GetCgroupPath(cg, ControllerCpu, "cpu.stat")
+	if err == ErrNoCgroup {
+		return false, err
+	}
+
+	fd, err := fsOpen(cg, path)
+	if err != nil {
+		return false, nil
+	}
+	defer fd.Close()
+
+	found := false
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 || parts[0] != "wait_sum" {
+			continue
+		}
+
+		value, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		stat.WaitTimeNs = value
+		found = true
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	path, _ = GetCgroupPath(cg, ControllerCpuacct, "cpuacct.usage")
+	values, err := readUintList(cg, path)
+	if err != nil || len(values) != 1 {
+		return false, nil
+	}
+
+	stat.RunTimeNs = values[0]
+	stat.CgroupLevel = true
+
+	if tids, err := GetTasks(cg); err == nil {
+		stat.Tasks = uint64(len(tids))
+	}
+
+	return true, nil
+}
+
+func populateCpuSchedTasks(cg Cgroup, stat *CpuSchedStat) error {
+	tids, err := GetTasks(cg)
+	if err != nil {
+		return err
+	}
+
+	for i := range tids {
+		tid := strconv.Itoa(tids[i])
+
+		contentsRaw, err := fsReadFile(cg, procPath(cg, tid, "task", tid, "schedstat"))
+		if err != nil {
+			// The task most likely exited in the meantime.
+			continue
+		}
+
+		fields := strings.Fields(string(contentsRaw))
+		if len(fields) != 3 {
+			continue
+		}
+
+		values := make([]uint64, 3)
+		for idx := range fields {
+			values[idx], err = strconv.ParseUint(fields[idx], 10, 64)
+			if err != nil {
+				break
+			}
+		}
+
+		if err != nil {
+			continue
+		}
+
+		stat.RunTimeNs += values[0]
+		stat.WaitTimeNs += values[1]
+		stat.Timeslices += values[2]
+		stat.Tasks++
+	}
+
+	return nil
+}
+
+func counterDelta(value uint64, prevValue uint64) uint64 {
+	if value < prevValue {
+		return 0
+	}
+
+	return value - prevValue
+}
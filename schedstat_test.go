@@ -0,0 +1,78 @@
+package cgroups
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestCpuSchedLatency(t *testing.T) {
+	for name, fsys := range fixtures.All {
+		stats, err := GetCpuSchedLatency(fixtureCgroup(fsys))
+
+		if err != nil {
+			t.Fail()
+		}
+
+		if stats.Tasks != 3 || stats.CgroupLevel {
+			t.Errorf("%s: %+v\n", name, stats)
+		}
+
+		if stats.RunTimeNs != 4600000000 || stats.WaitTimeNs != 550000000 || stats.Timeslices != 8800 {
+			t.Errorf("%s: %+v\n", name, stats)
+		}
+
+		t.Logf("%s: %+v\n", name, stats)
+	}
+}
+
+func TestCpuSchedLatencyCgroupLevel(t *testing.T) {
+	for _, fsys := range []fs.FS{fixtures.V1, fixtures.Hybrid} {
+		stats, err := GetCpuSchedLatency(Cgroup{FS: fsys, Cgroup: fixtures.SchedCgroup})
+
+		if err != nil || !stats.CgroupLevel {
+			t.Fatalf("%+v %v\n", stats, err)
+		}
+
+		if stats.WaitTimeNs != 750000000 || stats.RunTimeNs != 3000000000 || stats.Tasks != 2 || stats.Timeslices != 0 {
+			t.Errorf("%+v\n", stats)
+		}
+	}
+}
+
+func TestCpuSchedDelta(t *testing.T) {
+	now := time.Now()
+
+	prev := CpuSchedStat{RunTimeNs: 1000, WaitTimeNs: 5000, Timeslices: 10, SampleTime: now}
+	cur := CpuSchedStat{RunTimeNs: 2000, WaitTimeNs: 9000, Timeslices: 12, SampleTime: now.Add(time.Second)}
+
+	delta := cur.Delta(prev)
+
+	if delta.AvgWaitPerTimesliceNs != 2000 {
+		t.Fail()
+	}
+
+	if delta.WaitUsPerSec != 4.0 || delta.RunUsPerSec != 1.0 {
+		t.Fail()
+	}
+
+	// Exiting tasks may make the sums go backwards.
+	delta = prev.Delta(CpuSchedStat{RunTimeNs: 5000, SampleTime: now.Add(-time.Second)})
+
+	if delta.RunUsPerSec != 0 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", delta)
+}
+
+func BenchmarkCpuSchedLatency(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, err := GetCpuSchedLatency(fixtureCgroup(fixtures.V1))
+		if err != nil {
+			b.Fail()
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package cgroups
+
+import (
+	"time"
+)
+
+// CgroupStats is a snapshot of every controller for one cgroup. All
+// stats share the same SampleTime. Errors is keyed by controller; a
+// controller that failed is left zeroed and does not affect the others.
+type CgroupStats struct {
+	Cgroup Cgroup
+	Cpu    CpuStat
+	Memory MemoryStat
+	Blkio  BlkioStat
+	Net    NetItemizedStats
+	Pids   PidsStat
+	Psi    PsiStat
+	Errors map[string]error
+
+	// Per-device blkio stats, only read if StatsOptions.BlkioDevices
+	BlkioDevices BlkioItemizedStats
+
+	// The options the stats were read with
+	Options StatsOptions
+
+	SampleTime time.Time
+}
+
+// StatsOptions selects which controllers GetAllStats skips; the zero
+// value reads all of them.
+type StatsOptions struct {
+	SkipCpu    bool
+	SkipMemory bool
+	SkipBlkio  bool
+	SkipNet    bool
+	SkipPids   bool
+	SkipPsi    bool
+
+	BlkioDevices bool
+}
+
+type CgroupDeltaStats struct {
+	Cgroup   Cgroup
+	Cpu      CpuDeltaStat
+	Memory   MemoryDeltaStat
+	Blkio    BlkioDeltaStat
+	Net      NetDeltaStat
+	Errors   map[string]error
+	Options  StatsOptions /* of the later snapshot */
+	Interval time.Duration
+
+	SampleTime time.Time
+}
+
+const (
+	ControllerNet = "net"
+	ControllerPsi = "psi"
+)
+
+func (stats CgroupStats) Delta(prevStats CgroupStats) CgroupDeltaStats {
+	return CalcCgroupDeltaStats(stats, prevStats)
+}
+
+// CalcCgroupDeltaStats computes the deltas of every controller that was
+// read successfully in both snapshots; Net is the delta of the totals
+// over all interfaces but lo.
+func CalcCgroupDeltaStats(stats CgroupStats, prevStats CgroupStats) CgroupDeltaStats {
+	var deltaStat CgroupDeltaStats
+
+	deltaStat.Cgroup = stats.Cgroup
+	deltaStat.Errors = stats.Errors
+	deltaStat.Options = stats.Options
+	deltaStat.Interval = stats.SampleTime.Sub(prevStats.SampleTime)
+	deltaStat.SampleTime = stats.SampleTime
+
+	// Snapshots from GetAllStats already share one timestamp, but make
+	// sure of it for hand-built or decoded ones.
+	stats.shareSampleTime()
+	prevStats.shareSampleTime()
+
+	if statsComparable(stats, prevStats, ControllerCpu) {
+		deltaStat.Cpu = stats.Cpu.Delta(prevStats.Cpu)
+	}
+
+	if statsComparable(stats, prevStats, ControllerMemory) {
+		deltaStat.Memory = stats.Memory.Delta(prevStats.Memory)
+	}
+
+	if statsComparable(stats, prevStats, ControllerBlkio) {
+		deltaStat.Blkio = stats.Blkio.Delta(prevStats.Blkio)
+	}
+
+	if statsComparable(stats, prevStats, ControllerNet) {
+		// Total has no SampleTime if there are no interfaces but lo.
+		total := stats.Net.Total()
+		prevTotal := prevStats.Net.Total()
+		total.SampleTime = stats.SampleTime
+		prevTotal.SampleTime = prevStats.SampleTime
+
+		deltaStat.Net = total.Delta(prevTotal)
+	}
+
+	return deltaStat
+}
+
+// The delta calculations divide by the elapsed time in milliseconds, so
+// they need two successful readings at least that far apart.
+func statsComparable(stats CgroupStats, prevStats CgroupStats, controller string) bool {
+	if stats.SampleTime.IsZero() || prevStats.SampleTime.IsZero() {
+		return false
+	}
+
+	if stats.SampleTime.Sub(prevStats.SampleTime) < time.Millisecond {
+		return false
+	}
+
+	_, failed := stats.Errors[controller]
+	_, prevFailed := prevStats.Errors[controller]
+
+	return !failed && !prevFailed
+}
+
+// shareSampleTime sets the SampleTime of every stat to that of the
+// snapshot. The per-interface and per-device maps are copied rather than
+// modified in place, as they may be shared with the caller.
+func (stats *CgroupStats) shareSampleTime() {
+	stats.Cpu.SampleTime = stats.SampleTime
+	stats.Memory.SampleTime = stats.SampleTime
+	stats.Blkio.SampleTime = stats.SampleTime
+	stats.Pids.SampleTime = stats.SampleTime
+	stats.Psi.SampleTime = stats.SampleTime
+
+	if stats.Net.Stats != nil {
+		netStats := make(map[string]NetStat, len(stats.Net.Stats))
+		for intf, stat := range stats.Net.Stats {
+			stat.SampleTime = stats.SampleTime
+			netStats[intf] = stat
+		}
+		stats.Net.Stats = netStats
+	}
+
+	if stats.BlkioDevices.Stats != nil {
+		blkioStats := make(map[string]BlkioStat, len(stats.BlkioDevices.Stats))
+		for dev, stat := range stats.BlkioDevices.Stats {
+			stat.SampleTime = stats.SampleTime
+			blkioStats[dev] = stat
+		}
+		stats.BlkioDevices.Stats = blkioStats
+	}
+}
+
+// Collected reports whether the controller was read successfully, i.e.
+// it was neither skipped through Options nor failed.
+func (stats CgroupStats) Collected(controller string) bool {
+	return collected(stats.Errors, stats.Options, controller)
+}
+
+func (delta CgroupDeltaStats) Collected(controller string) bool {
+	return collected(delta.Errors, delta.Options, controller)
+}
+
+func collected(errs map[string]error, opts StatsOptions, controller string) bool {
+	if _, failed := errs[controller]; failed {
+		return false
+	}
+
+	switch controller {
+	case ControllerCpu:
+		return !opts.SkipCpu
+	case ControllerMemory:
+		return !opts.SkipMemory
+	case ControllerBlkio:
+		return !opts.SkipBlkio
+	case ControllerNet:
+		return !opts.SkipNet
+	case ControllerPids:
+		return !opts.SkipPids
+	case ControllerPsi:
+		return !opts.SkipPsi
+	}
+
+	return true
+}
+
+func GetAllStats(cg Cgroup, opts StatsOptions) CgroupStats {
+	var stats CgroupStats
+	var err error
+
+	stats.Cgroup = cg
+	stats.Options = opts
+	stats.Errors = make(map[string]error)
+	stats.SampleTime = time.Now()
+
+	if !opts.SkipCpu {
+		if stats.Cpu, err = GetCpuStats(cg); err != nil {
+			stats.Errors[ControllerCpu] = err
+			stats.Cpu = CpuStat{}
+		}
+	}
+
+	if !opts.SkipMemory {
+		if stats.Memory, err = GetMemoryStats(cg); err != nil {
+			stats.Errors[ControllerMemory] = err
+			stats.Memory = MemoryStat{}
+		}
+	}
+
+	if !opts.SkipBlkio {
+		if stats.Blkio, err = GetBlkioStats(cg); err != nil {
+			stats.Errors[ControllerBlkio] = err
+			stats.Blkio = BlkioStat{}
+		}
+
+		if opts.BlkioDevices {
+			if stats.BlkioDevices, err = GetBlkioItemizedStats(cg); err != nil {
+				stats.Errors[ControllerBlkio] = err
+			}
+		}
+	}
+
+	if !opts.SkipNet {
+		if stats.Net, err = GetNetItemizedStats(cg); err != nil {
+			stats.Errors[ControllerNet] = err
+			stats.Net = NetItemizedStats{Stats: make(map[string]NetStat)}
+		}
+	}
+
+	if !opts.SkipPids {
+		if stats.Pids, err = GetPidsStats(cg); err != nil {
+			stats.Errors[ControllerPids] = err
+			stats.Pids = PidsStat{}
+		}
+	}
+
+	if !opts.SkipPsi {
+		if stats.Psi, err = GetPsiStats(cg); err != nil {
+			stats.Errors[ControllerPsi] = err
+			stats.Psi = PsiStat{}
+		}
+	}
+
+	stats.shareSampleTime()
+
+	return stats
+}
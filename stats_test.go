@@ -0,0 +1,125 @@
+package cgroups
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/bwalex/go-cgroups/fixtures"
+)
+
+func TestAllStats(t *testing.T) {
+	root, err := ioutil.TempDir("", "go-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"cpu.stat":     "nr_periods 0\nnr_throttled 0\nthrottled_time 0\n",
+		"cpuacct.stat": "user 100\nsystem 50\n",
+		"pids.current": "12\n",
+		"pids.max":     "max\n",
+		"pids.events":  "max 3\n",
+		"cpu.pressure": "some avg10=1.50 avg60=0.75 avg300=0.25 total=123456\n",
+	}
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(path.Join(root, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cg := Cgroup{Root: root, Cgroup: "/"}
+
+	stats := GetAllStats(cg, StatsOptions{SkipNet: true})
+
+	if _, ok := stats.Errors[ControllerCpu]; ok {
+		t.Fail()
+	}
+
+	if _, ok := stats.Errors[ControllerMemory]; !ok {
+		t.Fail()
+	}
+
+	if _, ok := stats.Errors[ControllerNet]; ok {
+		t.Fail()
+	}
+
+	if stats.Cpu.SampleTime != stats.SampleTime || stats.Pids.SampleTime != stats.SampleTime {
+		t.Fail()
+	}
+
+	if stats.Cpu.UserTimeUs != ticksToUs(100) {
+		t.Fail()
+	}
+
+	if stats.Pids.Current != 12 || stats.Pids.Limit != 0 || stats.Pids.Max != 3 {
+		t.Fail()
+	}
+
+	if stats.Psi.Cpu.Some.Avg10 != 1.5 || stats.Psi.Cpu.Some.TotalUs != 123456 {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", stats)
+}
+
+func TestAllStatsDelta(t *testing.T) {
+	now := time.Now()
+
+	prev := CgroupStats{
+		Cpu:        CpuStat{UserTimeUs: 0, SampleTime: now},
+		Net:        NetItemizedStats{Stats: map[string]NetStat{"eth0": {RxBytes: 0}, "lo": {RxBytes: 0}}},
+		Errors:     map[string]error{ControllerBlkio: ErrNoCgroup},
+		SampleTime: now,
+	}
+
+	cur := CgroupStats{
+		Cpu:        CpuStat{UserTimeUs: 250000, SampleTime: now.Add(time.Second)},
+		Net:        NetItemizedStats{Stats: map[string]NetStat{"eth0": {RxBytes: 1000}, "lo": {RxBytes: 5000}}},
+		Errors:     map[string]error{},
+		SampleTime: now.Add(time.Second),
+	}
+
+	delta := cur.Delta(prev)
+
+	if delta.Cpu.UserUsagePct != 25.0 || delta.Net.RxByteRate != 1000 {
+		t.Fail()
+	}
+
+	if delta.Interval != time.Second {
+		t.Fail()
+	}
+
+	t.Logf("%+v\n", delta)
+}
+
+func TestAllStatsFixtures(t *testing.T) {
+	// Only PSI is missing, on v1
+	expected := map[string]int{"v1": 1, "hybrid": 0, "v2": 0}
+
+	for name, fsys := range fixtures.All {
+		stats := GetAllStats(fixtureCgroup(fsys), StatsOptions{BlkioDevices: true})
+
+		if len(stats.Errors) != expected[name] {
+			t.Errorf("%s: %+v\n", name, stats.Errors)
+		}
+
+		if stats.Pids.Current != 3 || stats.Memory.MemUsage != 402653184 || stats.Net.Total().TxBytes != 10485760 {
+			t.Errorf("%s: %+v\n", name, stats)
+		}
+
+		if name != "v1" && stats.Psi.Io.Full.TotalUs != 456789 {
+			t.Errorf("%s: %+v\n", name, stats.Psi)
+		}
+	}
+}
+
+func BenchmarkAllStats(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		GetAllStats(fixtureCgroup(fixtures.V1), StatsOptions{})
+	}
+}
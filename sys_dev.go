@@ -2,8 +2,6 @@ package cgroups
 
 import (
 	"bufio"
-	"os"
-	"path"
 	"strings"
 	"sync"
 )
@@ -13,35 +11,60 @@ var blockDeviceCache struct {
 	cache map[string]string
 }
 
+// The device names are read from SysDevBlockRoot, below Cgroup.SysRoot if
+// set.
 const (
 	SysDevBlockRoot = "/sys/dev/block"
 )
 
 func GetBlockDeviceFromMajMin(majMin string) string {
+	return Host{}.GetBlockDeviceFromMajMin(majMin)
+}
+
+// blockDeviceName looks up the device name in the filesystem of cg. Only
+// names from the host's filesystem are cached, by sysfs path.
+func blockDeviceName(cg Cgroup, majMin string) string {
+	if cg.FS != nil {
+		name, _ := readBlockDeviceName(cg, majMin)
+		return name
+	}
+
 	blockDeviceCache.Lock()
 	defer blockDeviceCache.Unlock()
 
-	if dev, ok := blockDeviceCache.cache[majMin]; ok {
+	if blockDeviceCache.cache == nil {
+		blockDeviceCache.cache = make(map[string]string)
+	}
+
+	key := sysPath(cg, "dev/block", majMin)
+	if dev, ok := blockDeviceCache.cache[key]; ok {
 		return dev
 	}
 
-	fd, err := os.Open(path.Join(SysDevBlockRoot, majMin, "uevent"))
+	name, found := readBlockDeviceName(cg, majMin)
+	if found {
+		blockDeviceCache.cache[key] = name
+	}
+
+	return name
+}
+
+func readBlockDeviceName(cg Cgroup, majMin string) (string, bool) {
+	fd, err := fsOpen(cg, sysPath(cg, "dev/block", majMin, "uevent"))
 	if err != nil {
-		return majMin
+		return majMin, false
 	}
 	defer fd.Close()
 
 	scanner := bufio.NewScanner(fd)
 	for scanner.Scan() {
 		parts := strings.Split(strings.TrimSpace(scanner.Text()), "=")
-		if strings.ToLower(parts[0]) != "devtype" {
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "devname" {
 			continue
 		}
 
-		blockDeviceCache.cache[majMin] = parts[1]
-
-		return parts[1]
+		return parts[1], true
 	}
 
-	return majMin
+	return majMin, false
 }
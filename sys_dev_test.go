@@ -0,0 +1,36 @@
+package cgroups
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestBlockDeviceFromMajMin(t *testing.T) {
+	entries, err := ioutil.ReadDir(SysDevBlockRoot)
+	if err != nil || len(entries) == 0 {
+		t.Skip("no block devices")
+	}
+
+	for _, entry := range entries {
+		majMin := entry.Name()
+
+		target, err := os.Readlink(path.Join(SysDevBlockRoot, majMin))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The sysfs link ends in the kernel's name for the device,
+		// which is what DEVNAME in its uevent holds.
+		for i := 0; i < 2; i++ {
+			if dev := GetBlockDeviceFromMajMin(majMin); dev != path.Base(target) {
+				t.Errorf("%s: expected %s, got %s\n", majMin, path.Base(target), dev)
+			}
+		}
+	}
+
+	if dev := GetBlockDeviceFromMajMin("0:0"); dev != "0:0" {
+		t.Errorf("expected 0:0, got %s\n", dev)
+	}
+}